@@ -0,0 +1,334 @@
+package gojsondiff
+
+import (
+	"container/list"
+	"reflect"
+	"strings"
+
+	"github.com/yudai/golcs"
+)
+
+const (
+	// defaultHashAlignmentThreshold is the default number of elements of two
+	// arrays from which they are aligned by hashing.
+	defaultHashAlignmentThreshold = 1000
+	// maxMyersCost is the number of edits from which arrays aligned by
+	// hashing are split by histogram diffs instead of Myers' algorithm.
+	maxMyersCost = 256
+	// maxHistogramChain is the number of occurrences from which elements are
+	// not used as anchors of histogram diffs.
+	maxHistogramChain = 64
+	// maxSimilarityCells limits the size of similarity tables between
+	// deleted and added elements of arrays aligned by hashing.
+	maxSimilarityCells = 4096
+	// maxMoveCandidates limits the number of added elements compared with
+	// each deleted element when finding moved and modified elements.
+	maxMoveCandidates = 64
+)
+
+// WithHashAlignmentThreshold sets the total number of elements of two arrays
+// from which they are aligned by hashing their elements instead of an LCS
+// table. Hashed arrays are aligned by Myers' algorithm, and split by
+// histogram diffs when they have many changes, which takes time nearly linear
+// to the number of elements, while an LCS table takes quadratic time and
+// memory. The Deltas are the same unless the arrays have more than one longest
+// common subsequence or many changes.
+// Zero aligns all arrays by hashing. The default is 1000.
+func WithHashAlignmentThreshold(elements int) Option {
+	return func(differ *Differ) {
+		differ.hashAlignmentThreshold = elements
+	}
+}
+
+// alignArrays returns the index pairs of common elements of the arrays.
+// Elements are identified by ids when the arrays are aligned by hashing,
+// otherwise ids are nil.
+func (differ *Differ) alignArrays(left, right []interface{}) (pairs []lcs.IndexPair, leftIDs, rightIDs []int) {
+	if len(left)+len(right) < differ.hashAlignmentThreshold {
+		return lcs.New(left, right).IndexPairs(), nil, nil
+	}
+	leftIDs, rightIDs = elementIDs(left, right)
+	pairs = make([]lcs.IndexPair, 0)
+	histogramAlign(leftIDs, rightIDs, 0, 0, &pairs)
+	return pairs, leftIDs, rightIDs
+}
+
+// elementIDs numbers the elements of the arrays so that equal elements have
+// the same id. Elements are grouped by their hashes as in CompareStreams, and
+// compared only with elements of the same hash.
+func elementIDs(left, right []interface{}) (leftIDs, rightIDs []int) {
+	buckets := map[uint64][]int{}
+	representatives := make([]interface{}, 0)
+	identify := func(values []interface{}) []int {
+		ids := make([]int, len(values))
+	values:
+		for i, value := range values {
+			// values failing to hash are compared in the same bucket
+			hash, _ := hashValue(value)
+			for _, id := range buckets[hash] {
+				if reflect.DeepEqual(representatives[id], value) {
+					ids[i] = id
+					continue values
+				}
+			}
+			ids[i] = len(representatives)
+			buckets[hash] = append(buckets[hash], ids[i])
+			representatives = append(representatives, value)
+		}
+		return ids
+	}
+	return identify(left), identify(right)
+}
+
+// histogramAlign appends the index pairs of common elements of a and b.
+// Arrays within maxMyersCost edits are aligned by Myers' algorithm, which
+// finds a longest common subsequence. Otherwise, like the histogram diff of
+// git, they are split at the longest common run containing the least
+// frequent element, and both sides of the run are aligned recursively.
+// Arrays whose common elements are all too frequent are aligned by Myers'
+// algorithm regardless of the cost.
+func histogramAlign(a, b []int, aOffset, bOffset int, pairs *[]lcs.IndexPair) {
+	prefix, suffix := commonAffixes(a, b)
+	appendRun(pairs, aOffset, bOffset, prefix)
+	innerA, innerB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if len(innerA) > 0 && len(innerB) > 0 {
+		if x, y, u, v, ok := middleSnake(innerA, innerB, maxMyersCost); ok {
+			histogramAlign(innerA[:x], innerB[:y], aOffset+prefix, bOffset+prefix, pairs)
+			appendRun(pairs, aOffset+prefix+x, bOffset+prefix+y, u-x)
+			histogramAlign(innerA[u:], innerB[v:], aOffset+prefix+u, bOffset+prefix+v, pairs)
+		} else {
+			aStart, bStart, length, found, common := histogramAnchor(innerA, innerB)
+			switch {
+			case found:
+				histogramAlign(innerA[:aStart], innerB[:bStart], aOffset+prefix, bOffset+prefix, pairs)
+				appendRun(pairs, aOffset+prefix+aStart, bOffset+prefix+bStart, length)
+				histogramAlign(innerA[aStart+length:], innerB[bStart+length:], aOffset+prefix+aStart+length, bOffset+prefix+bStart+length, pairs)
+			case common:
+				myersAlign(innerA, innerB, aOffset+prefix, bOffset+prefix, pairs)
+			}
+		}
+	}
+
+	appendRun(pairs, aOffset+len(a)-suffix, bOffset+len(b)-suffix, suffix)
+}
+
+// histogramAnchor finds the longest common run containing the least frequent
+// element of a. common reports whether a and b have any common elements.
+func histogramAnchor(a, b []int) (aStart, bStart, length int, found, common bool) {
+	occurrences := map[int][]int{}
+	for i, id := range a {
+		occurrences[id] = append(occurrences[id], i)
+	}
+
+	bestCount := maxHistogramChain
+	for j := 0; j < len(b); {
+		positions := occurrences[b[j]]
+		if len(positions) == 0 {
+			j++
+			continue
+		}
+		common = true
+		if len(positions) > maxHistogramChain || len(positions) > bestCount {
+			j++
+			continue
+		}
+		next := j + 1
+		for _, i := range positions {
+			s, t := i, j
+			for s > 0 && t > 0 && a[s-1] == b[t-1] {
+				s--
+				t--
+			}
+			e, f := i+1, j+1
+			for e < len(a) && f < len(b) && a[e] == b[f] {
+				e++
+				f++
+			}
+			if len(positions) < bestCount || e-s > length {
+				aStart, bStart, length, bestCount = s, t, e-s, len(positions)
+				found = true
+			}
+			if f > next {
+				next = f
+			}
+		}
+		j = next
+	}
+	return
+}
+
+// myersAlign appends the index pairs of a longest common subsequence of a
+// and b found by the linear space variant of Myers' algorithm.
+func myersAlign(a, b []int, aOffset, bOffset int, pairs *[]lcs.IndexPair) {
+	prefix, suffix := commonAffixes(a, b)
+	appendRun(pairs, aOffset, bOffset, prefix)
+	innerA, innerB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// without common affixes, arrays at distance 1 have an empty side
+	if len(innerA) > 0 && len(innerB) > 0 {
+		x, y, u, v, _ := middleSnake(innerA, innerB, len(innerA)+len(innerB))
+		myersAlign(innerA[:x], innerB[:y], aOffset+prefix, bOffset+prefix, pairs)
+		appendRun(pairs, aOffset+prefix+x, bOffset+prefix+y, u-x)
+		myersAlign(innerA[u:], innerB[v:], aOffset+prefix+u, bOffset+prefix+v, pairs)
+	}
+
+	appendRun(pairs, aOffset+len(a)-suffix, bOffset+len(b)-suffix, suffix)
+}
+
+// middleSnake returns the snake from (x, y) to (u, v) in the middle of a
+// shortest edit script of a and b. ok is false when the script has more than
+// cost edits.
+func middleSnake(a, b []int, cost int) (x, y, u, v int, ok bool) {
+	n, m := len(a), len(b)
+	max := (n + m + 1) / 2
+	if limit := (cost + 1) / 2; limit < max {
+		max = limit
+	}
+	delta := n - m
+	odd := delta%2 != 0
+	offset := max + 1
+	// furthest x on each diagonal k = x - y, forward from the start and
+	// backward from the end of the arrays
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			forward[offset+k] = u
+			// the backward diagonal delta-k was reached at d-1
+			if odd && delta-k >= -(d-1) && delta-k <= d-1 && u+backward[offset+delta-k] >= n {
+				return x, y, u, v, true
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var s int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				s = backward[offset+k+1]
+			} else {
+				s = backward[offset+k-1] + 1
+			}
+			t := s - k
+			e, f := s, t
+			for e < n && f < m && a[n-e-1] == b[m-f-1] {
+				e++
+				f++
+			}
+			backward[offset+k] = e
+			if !odd && delta-k >= -d && delta-k <= d && e+forward[offset+delta-k] >= n {
+				return n - e, m - f, n - s, m - t, true
+			}
+		}
+	}
+	// the paths overlap at the latest when d is (n+m+1)/2
+	return 0, 0, 0, 0, false
+}
+
+func commonAffixes(a, b []int) (prefix, suffix int) {
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-suffix-1] == b[len(b)-suffix-1] {
+		suffix++
+	}
+	return
+}
+
+func appendRun(pairs *[]lcs.IndexPair, aStart, bStart, length int) {
+	for i := 0; i < length; i++ {
+		*pairs = append(*pairs, lcs.IndexPair{Left: aStart + i, Right: bStart + i})
+	}
+}
+
+// findMovesByID pairs deleted and added elements with the same ids, and
+// removes the pairs from the lists. Like the search by reflect.DeepEqual,
+// each deleted element is paired with the first added element equal to it.
+func findMovesByID(maybeDeleted, maybeAdded *list.List, leftIDs, rightIDs []int, elem *valuePolicy) (deltas []Delta) {
+	added := map[int][]*list.Element{}
+	for addElement := maybeAdded.Front(); addElement != nil; addElement = addElement.Next() {
+		id := rightIDs[addElement.Value.(maybe).index]
+		added[id] = append(added[id], addElement)
+	}
+
+	deltas = make([]Delta, 0)
+	var delNext *list.Element
+	for delElement := maybeDeleted.Front(); delElement != nil; delElement = delNext {
+		delNext = delElement.Next()
+		del := delElement.Value.(maybe)
+		candidates := added[leftIDs[del.index]]
+		if len(candidates) == 0 {
+			continue
+		}
+		addElement := candidates[0]
+		added[leftIDs[del.index]] = candidates[1:]
+		deltas = append(deltas, NewMoved(Index(del.index), Index(addElement.Value.(maybe).index), elem.hide(del.item), nil))
+		maybeAdded.Remove(addElement)
+		maybeDeleted.Remove(delElement)
+	}
+	return deltas
+}
+
+// pairByPosition pairs deleted and added elements in their order, for gaps
+// too large for maximizeSimilarities. Pairs less similar than the threshold
+// are left as deleted and added.
+func (differ *Differ) pairByPosition(path []Position, left []maybe, right []maybe, policy *valuePolicy) (resultDeltas []Delta, freeLeft, freeRight []maybe) {
+	resultDeltas = make([]Delta, 0)
+	freeLeft = make([]maybe, 0)
+	freeRight = make([]maybe, 0)
+	for i := 0; i < len(left) || i < len(right); i++ {
+		if i >= len(left) {
+			freeRight = append(freeRight, right[i])
+			continue
+		}
+		if i >= len(right) {
+			freeLeft = append(freeLeft, left[i])
+			continue
+		}
+		same, delta := differ.compareValues(path, Index(right[i].index), left[i].item, right[i].item, policy)
+		switch {
+		case same:
+		case delta.Similarity() >= differ.similarityThreshold:
+			resultDeltas = append(resultDeltas, delta)
+		default:
+			freeLeft = append(freeLeft, left[i])
+			freeRight = append(freeRight, right[i])
+		}
+	}
+	return
+}
+
+// valueShape returns a key grouping values that can be similar, such as
+// objects with the same keys.
+func valueShape(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "{" + strings.Join(sortedKeys(v), "\x00")
+	case []interface{}:
+		return "["
+	}
+	return reflect.TypeOf(value).String()
+}
+
+// shapeBuckets groups the values in the list by their shapes.
+func shapeBuckets(values *list.List) map[string][]*list.Element {
+	shapes := map[string][]*list.Element{}
+	for element := values.Front(); element != nil; element = element.Next() {
+		shape := valueShape(element.Value.(maybe).plain)
+		shapes[shape] = append(shapes[shape], element)
+	}
+	return shapes
+}
@@ -0,0 +1,150 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/yudai/gojsondiff/formatter"
+	. "github.com/yudai/gojsondiff/tests"
+
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// formatDelta formats the diff in the delta format, which is the same for
+// equal Deltas regardless of cached similarities.
+func formatDelta(d Diff) string {
+	result, err := formatter.NewDeltaFormatter().Format(d)
+	Expect(err).NotTo(HaveOccurred())
+	return result
+}
+
+var fixturePairs = [][2]string{
+	{"FIXTURES/base.json", "FIXTURES/base_changed.json"},
+	{"FIXTURES/add_delete_from.json", "FIXTURES/add_delete_to.json"},
+	{"FIXTURES/changed_types_from.json", "FIXTURES/changed_types_to.json"},
+	{"FIXTURES/long_text_from.json", "FIXTURES/long_text_to.json"},
+	{"FIXTURES/move_from.json", "FIXTURES/move_to.json"},
+}
+
+// editedArray returns an array of objects and the array with elements
+// deleted, inserted, modified and moved at random.
+func editedArray(size int, seed int64) (left, right []interface{}) {
+	random := rand.New(rand.NewSource(seed))
+	item := func(id int) map[string]interface{} {
+		return map[string]interface{}{"id": float64(id), "name": "item", "tags": []interface{}{"a", float64(id % 7)}}
+	}
+	left = make([]interface{}, size)
+	for i := range left {
+		left[i] = item(random.Intn(size))
+	}
+	right = make([]interface{}, 0, size)
+	for _, value := range left {
+		switch random.Intn(20) {
+		case 0:
+		case 1:
+			right = append(right, item(size+random.Intn(size)), value)
+		case 2:
+			modified := item(int(value.(map[string]interface{})["id"].(float64)))
+			modified["name"] = "modified"
+			right = append(right, modified)
+		default:
+			right = append(right, value)
+		}
+	}
+	for i := 0; i < size/100; i++ {
+		from, to := random.Intn(len(right)), random.Intn(len(right))
+		right[from], right[to] = right[to], right[from]
+	}
+	return left, copyArray(right)
+}
+
+// copyArray returns a deep copy of the array, so that patching one of the
+// arrays returned by editedArray does not modify the other.
+func copyArray(array []interface{}) []interface{} {
+	encoded, err := json.Marshal(array)
+	if err != nil {
+		panic(err)
+	}
+	var copied []interface{}
+	if err := json.Unmarshal(encoded, &copied); err != nil {
+		panic(err)
+	}
+	return copied
+}
+
+var _ = Describe("Array alignment by hashing", func() {
+	It("returns the same deltas as the LCS table for fixtures", func() {
+		for _, pair := range fixturePairs {
+			left, right := LoadFixture(pair[0]), LoadFixture(pair[1])
+			expected := formatDelta(New().CompareObjects(left, right))
+			Expect(formatDelta(New(WithHashAlignmentThreshold(0)).CompareObjects(left, right))).To(Equal(expected), pair[0])
+		}
+
+		left, right := LoadFixtureAsArray("FIXTURES/array.json"), LoadFixtureAsArray("FIXTURES/array_changed.json")
+		expected := formatDelta(New().CompareArrays(left, right))
+		Expect(formatDelta(New(WithHashAlignmentThreshold(0)).CompareArrays(left, right))).To(Equal(expected))
+	})
+
+	It("returns patches producing the right arrays", func() {
+		for seed := int64(0); seed < 20; seed++ {
+			left, right := editedArray(200, seed)
+			for _, differ := range []*Differ{New(), New(WithHashAlignmentThreshold(0))} {
+				d := differ.CompareArrays(left, right)
+				patched := New().ApplyPatchToArray(copyArray(left), d)
+				Expect(patched).To(Equal(right))
+			}
+		}
+	})
+
+	It("aligns large arrays", func() {
+		left, right := editedArray(20000, 1)
+		d := New(WithMoveSimilarityThreshold(0.5)).CompareArrays(left, right)
+		Expect(New().ApplyPatchToArray(copyArray(left), d)).To(Equal(right))
+	})
+})
+
+func benchmarkCompareFixtures(b *testing.B, differ *Differ) {
+	lefts, rights := []map[string]interface{}{}, []map[string]interface{}{}
+	for _, pair := range fixturePairs {
+		lefts = append(lefts, LoadFixture(pair[0]))
+		rights = append(rights, LoadFixture(pair[1]))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range lefts {
+			differ.CompareObjects(lefts[j], rights[j])
+		}
+	}
+}
+
+func BenchmarkCompareFixturesByLCS(b *testing.B) {
+	benchmarkCompareFixtures(b, New())
+}
+
+func BenchmarkCompareFixturesByHash(b *testing.B) {
+	benchmarkCompareFixtures(b, New(WithHashAlignmentThreshold(0)))
+}
+
+func benchmarkCompareArrays(b *testing.B, size int, differ *Differ) {
+	left, right := editedArray(size, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		differ.CompareArrays(left, right)
+	}
+}
+
+func BenchmarkCompareArrays1kByLCS(b *testing.B) {
+	benchmarkCompareArrays(b, 1000, New(WithHashAlignmentThreshold(math.MaxInt32)))
+}
+
+func BenchmarkCompareArrays1kByHash(b *testing.B) {
+	benchmarkCompareArrays(b, 1000, New())
+}
+
+func BenchmarkCompareArrays50kByHash(b *testing.B) {
+	benchmarkCompareArrays(b, 50000, New())
+}
@@ -0,0 +1,127 @@
+package gojsondiff
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// budgetCheckInterval is the number of compared values between checks of the
+// context and the time budget.
+const budgetCheckInterval = 256
+
+// WithMaxNodes limits the number of values compared by a call of the Differ.
+// Once the limit is reached, the remaining objects and arrays are compared as
+// a whole and reported as Modified, and elements of arrays and keys of objects
+// are no longer paired by similarity. Zero means no limit, which is the
+// default.
+func WithMaxNodes(nodes int) Option {
+	return func(differ *Differ) {
+		differ.maxNodes = nodes
+	}
+}
+
+// WithMaxSimilarityCells limits the number of pairs of deleted and added
+// values compared to find modified, moved and renamed values in an array or
+// an object. Elements of larger arrays are paired by position, and moved
+// elements are searched among elements of the same shape. Keys of larger
+// objects are not detected as renamed. Zero means no limit, which is the
+// default.
+func WithMaxSimilarityCells(cells int) Option {
+	return func(differ *Differ) {
+		differ.maxSimilarityCells = cells
+	}
+}
+
+// WithTimeBudget limits the time spent by a call of the Differ. Once the time
+// is up, the comparison is finished in the same way as WithMaxNodes. Zero
+// means no limit, which is the default.
+func WithTimeBudget(budget time.Duration) Option {
+	return func(differ *Differ) {
+		differ.timeBudget = budget
+	}
+}
+
+// A budget tracks the resources spent by a call of a Differ. It is shared by
+// the goroutines of the call.
+type budget struct {
+	ctx      context.Context
+	deadline time.Time
+	maxNodes int64
+	nodes    int64
+	spent    int32
+	mutex    sync.Mutex
+	err      error
+	// workers holds a token for each goroutine comparing values in addition
+	// to the calling goroutine
+	workers chan struct{}
+}
+
+// start returns the Differ with a budget for a call with the context.
+// The Differ is returned as is when it has no limits and compares values
+// sequentially, or already has a budget.
+func (differ *Differ) start(ctx context.Context) *Differ {
+	if differ.budget != nil ||
+		(ctx.Done() == nil && differ.maxNodes <= 0 && differ.timeBudget <= 0 && differ.concurrency <= 1) {
+		return differ
+	}
+	started := *differ
+	started.budget = &budget{ctx: ctx, maxNodes: int64(differ.maxNodes)}
+	if differ.timeBudget > 0 {
+		started.budget.deadline = time.Now().Add(differ.timeBudget)
+	}
+	if differ.concurrency > 1 {
+		started.budget.workers = make(chan struct{}, differ.concurrency-1)
+	}
+	return &started
+}
+
+// spend counts a compared value, and returns false once the budget is spent.
+func (b *budget) spend() bool {
+	if b == nil {
+		return true
+	}
+	if b.exhausted() {
+		return false
+	}
+	nodes := atomic.AddInt64(&b.nodes, 1)
+	if b.maxNodes > 0 && nodes > b.maxNodes {
+		atomic.StoreInt32(&b.spent, 1)
+	} else if nodes%budgetCheckInterval == 0 {
+		if err := b.ctx.Err(); err != nil {
+			b.mutex.Lock()
+			b.err = err
+			b.mutex.Unlock()
+			atomic.StoreInt32(&b.spent, 1)
+		} else if !b.deadline.IsZero() && time.Now().After(b.deadline) {
+			atomic.StoreInt32(&b.spent, 1)
+		}
+	}
+	return !b.exhausted()
+}
+
+// exhausted reports whether the budget is spent.
+func (b *budget) exhausted() bool {
+	return b != nil && atomic.LoadInt32(&b.spent) != 0
+}
+
+// error returns the error of the context when it ended the call.
+func (b *budget) error() error {
+	if b == nil {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.err == nil {
+		// a context ended after the last check
+		b.err = b.ctx.Err()
+	}
+	return b.err
+}
+
+// exceedsSimilarityCells reports whether comparing the number of pairs of
+// values is over the limit.
+func (differ *Differ) exceedsSimilarityCells(cells int) bool {
+	return differ.maxSimilarityCells > 0 && cells > differ.maxSimilarityCells
+}
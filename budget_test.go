@@ -0,0 +1,89 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"context"
+	"time"
+)
+
+var _ = Describe("Budgets", func() {
+	document := func(name string) map[string]interface{} {
+		items := make([]interface{}, 0, 50)
+		for i := 0; i < 50; i++ {
+			items = append(items, map[string]interface{}{"id": float64(i), "name": name, "tags": []interface{}{"a", "b"}})
+		}
+		return map[string]interface{}{"items": items, "meta": map[string]interface{}{"name": name}}
+	}
+
+	It("stops comparisons with the error of the context", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := New().CompareObjectsContext(ctx, document("a"), document("b"))
+		Expect(err).To(Equal(context.Canceled))
+		_, err = New().CompareContext(ctx, []byte(`{"a": 1}`), []byte(`{"a": 2}`))
+		Expect(err).To(Equal(context.Canceled))
+		_, err = New().CompareArraysContext(ctx, []interface{}{1.0}, []interface{}{2.0})
+		Expect(err).To(Equal(context.Canceled))
+		for _, opt := range []Option{WithLenient(true), WithPreserveKeyOrder(true), WithRecordPositions(true)} {
+			_, err = New(opt).CompareContext(ctx, []byte(`{"a": 1}`), []byte(`{"a": 2}`))
+			Expect(err).To(Equal(context.Canceled))
+		}
+
+		ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		_, err = New().CompareValuesContext(ctx, document("a"), document("b"))
+		Expect(err).To(Equal(context.DeadlineExceeded))
+	})
+
+	It("compares with live contexts as without them", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		d, err := New().CompareObjectsContext(ctx, document("a"), document("b"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.Deltas()).To(Equal(New().CompareObjects(document("a"), document("b")).Deltas()))
+	})
+
+	It("reports coarser deltas once the nodes are spent", func() {
+		d := New(WithMaxNodes(10)).CompareObjects(document("a"), document("b"))
+		Expect(d.Deltas()).To(ContainElement(BeAssignableToTypeOf(&Modified{})))
+
+		patched := document("a")
+		New().ApplyPatch(patched, d)
+		Expect(patched).To(Equal(document("b")))
+
+		Expect(New(WithMaxNodes(100000)).CompareObjects(document("a"), document("b")).Deltas()).
+			To(Equal(New().CompareObjects(document("a"), document("b")).Deltas()))
+	})
+
+	It("reports coarser deltas once the time is up", func() {
+		d := New(WithTimeBudget(time.Nanosecond)).CompareObjects(document("a"), document("b"))
+		patched := document("a")
+		New().ApplyPatch(patched, d)
+		Expect(patched).To(Equal(document("b")))
+	})
+
+	It("pairs elements of large gaps by position", func() {
+		left, right := make([]interface{}, 0), make([]interface{}, 0)
+		for i := 0; i < 40; i++ {
+			left = append(left, map[string]interface{}{"id": float64(i), "name": "old"})
+			right = append(right, map[string]interface{}{"id": float64(i), "name": "new"})
+		}
+		d := New(WithMaxSimilarityCells(100)).CompareArrays(left, right)
+		Expect(d.Deltas()).To(HaveLen(40))
+		Expect(d.Deltas()[0]).To(Equal(NewObject(Index(0), []Delta{NewModified(Name("name"), "old", "new")})))
+		Expect(New().ApplyPatchToArray(copyArray(left), d)).To(Equal(right))
+	})
+
+	It("skips renames of large objects", func() {
+		left := map[string]interface{}{"a": "x", "b": "y"}
+		right := map[string]interface{}{"c": "x", "d": "y"}
+		Expect(New(WithRenameDetection(1)).CompareObjects(left, right).Deltas()).To(HaveLen(2))
+		Expect(New(WithRenameDetection(1), WithMaxSimilarityCells(3)).CompareObjects(left, right).Deltas()).To(HaveLen(4))
+	})
+})
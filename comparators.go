@@ -0,0 +1,150 @@
+package gojsondiff
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// A Comparison is the result of a Comparator.
+type Comparison int
+
+const (
+	// CompareDefault compares the values with the default logic
+	CompareDefault Comparison = iota
+	// CompareEqual treats the values as the same
+	CompareEqual
+	// CompareNotEqual reports the values as Modified
+	CompareNotEqual
+)
+
+// A Comparator compares two values at the path. When it returns a Delta, the
+// Delta is reported regardless of the Comparison. The Delta must have the
+// last position of the path.
+type Comparator func(path []Position, left, right interface{}) (Comparison, Delta)
+
+// WithComparator adds a comparator consulted for all values. Comparators can
+// return CompareDefault for values they do not handle, such as values of
+// other shapes.
+func WithComparator(comparator Comparator) Option {
+	return WithCompareHook(comparator.hook)
+}
+
+// WithPathComparator adds a comparator consulted for values at paths matching
+// the pattern. The pattern is a JSON Pointer, such as "/items/*/createdAt",
+// where "*" matches any key or index and "**" matches any number of them.
+func WithPathComparator(pattern string, comparator Comparator) Option {
+	segments := parsePattern(pattern)
+	return WithCompareHook(func(path []Position, left, right interface{}) (Delta, bool) {
+		if !matchPattern(segments, path) {
+			return nil, false
+		}
+		return comparator.hook(path, left, right)
+	})
+}
+
+func (comparator Comparator) hook(path []Position, left, right interface{}) (Delta, bool) {
+	comparison, delta := comparator(path, left, right)
+	if delta != nil {
+		return delta, true
+	}
+	switch comparison {
+	case CompareEqual:
+		return nil, true
+	case CompareNotEqual:
+		return NewModified(path[len(path)-1], left, right), true
+	}
+	return nil, false
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func parsePattern(pattern string) []string {
+	if pattern == "" {
+		return []string{}
+	}
+	segments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = pointerUnescaper.Replace(segment)
+	}
+	return segments
+}
+
+func matchPattern(segments []string, path []Position) bool {
+	if len(segments) == 0 {
+		return len(path) == 0
+	}
+	switch segments[0] {
+	case "**":
+		for i := 0; i <= len(path); i++ {
+			if matchPattern(segments[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(path) > 0 && matchPattern(segments[1:], path[1:])
+	}
+	return len(path) > 0 && path[0].String() == segments[0] && matchPattern(segments[1:], path[1:])
+}
+
+// TimeComparator treats RFC 3339 timestamps denoting the same instant as
+// equal, such as "2020-01-01T09:00:00+09:00" and "2020-01-01T00:00:00Z".
+func TimeComparator(path []Position, left, right interface{}) (Comparison, Delta) {
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if !lok || !rok {
+		return CompareDefault, nil
+	}
+	leftTime, err := time.Parse(time.RFC3339Nano, l)
+	if err != nil {
+		return CompareDefault, nil
+	}
+	rightTime, err := time.Parse(time.RFC3339Nano, r)
+	if err != nil {
+		return CompareDefault, nil
+	}
+	if leftTime.Equal(rightTime) {
+		return CompareEqual, nil
+	}
+	return CompareDefault, nil
+}
+
+// URLComparator treats absolute URLs as equal regardless of the order of
+// their query parameters and the case of their schemes and hosts.
+func URLComparator(path []Position, left, right interface{}) (Comparison, Delta) {
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if !lok || !rok {
+		return CompareDefault, nil
+	}
+	leftURL, err := url.Parse(l)
+	if err != nil || !leftURL.IsAbs() {
+		return CompareDefault, nil
+	}
+	rightURL, err := url.Parse(r)
+	if err != nil || !rightURL.IsAbs() {
+		return CompareDefault, nil
+	}
+	if strings.EqualFold(leftURL.Scheme, rightURL.Scheme) &&
+		strings.EqualFold(leftURL.Host, rightURL.Host) &&
+		leftURL.User.String() == rightURL.User.String() &&
+		leftURL.EscapedPath() == rightURL.EscapedPath() &&
+		leftURL.Fragment == rightURL.Fragment &&
+		reflect.DeepEqual(leftURL.Query(), rightURL.Query()) {
+		return CompareEqual, nil
+	}
+	return CompareDefault, nil
+}
+
+// CaseInsensitiveComparator treats strings equal under Unicode case folding
+// as equal, such as enum values "Active" and "ACTIVE".
+func CaseInsensitiveComparator(path []Position, left, right interface{}) (Comparison, Delta) {
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if lok && rok && strings.EqualFold(l, r) {
+		return CompareEqual, nil
+	}
+	return CompareDefault, nil
+}
@@ -0,0 +1,79 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Comparators", func() {
+	compare := func(differ *Differ, left, right map[string]interface{}) []Delta {
+		return differ.CompareObjects(left, right).Deltas()
+	}
+
+	It("treats equivalent timestamps, URLs and strings as equal", func() {
+		left := map[string]interface{}{
+			"createdAt": "2020-01-01T09:00:00+09:00",
+			"url":       "HTTPS://Example.com/path?b=2&a=1",
+			"status":    "Active",
+		}
+		right := map[string]interface{}{
+			"createdAt": "2020-01-01T00:00:00Z",
+			"url":       "https://example.com/path?a=1&b=2",
+			"status":    "ACTIVE",
+		}
+		Expect(compare(New(), left, right)).To(HaveLen(3))
+
+		differ := New(
+			WithComparator(TimeComparator),
+			WithComparator(URLComparator),
+			WithPathComparator("/status", CaseInsensitiveComparator),
+		)
+		Expect(compare(differ, left, right)).To(BeEmpty())
+
+		right["createdAt"] = "2020-01-01T00:00:01Z"
+		right["url"] = "https://example.com/other?a=1&b=2"
+		right["status"] = "inactive"
+		Expect(compare(differ, left, right)).To(HaveLen(3))
+	})
+
+	It("matches paths with wildcards", func() {
+		left := map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "A", "meta": map[string]interface{}{"name": "X"}},
+			},
+			"name": "B",
+		}
+		right := map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "a", "meta": map[string]interface{}{"name": "x"}},
+			},
+			"name": "b",
+		}
+
+		differ := New(WithPathComparator("/items/*/name", CaseInsensitiveComparator))
+		deltas := compare(differ, left, right)
+		Expect(deltas).To(HaveLen(2))
+
+		differ = New(WithPathComparator("/**/name", CaseInsensitiveComparator))
+		Expect(compare(differ, left, right)).To(BeEmpty())
+	})
+
+	It("reports values as not equal or with custom deltas", func() {
+		notEqual := func(path []Position, left, right interface{}) (Comparison, Delta) {
+			return CompareNotEqual, nil
+		}
+		custom := func(path []Position, left, right interface{}) (Comparison, Delta) {
+			return CompareDefault, NewModified(path[len(path)-1], "old", "new")
+		}
+		left := map[string]interface{}{"a": map[string]interface{}{"x": 1.0}, "b": 1.0}
+		right := map[string]interface{}{"a": map[string]interface{}{"x": 1.0}, "b": 2.0}
+
+		deltas := compare(New(WithPathComparator("/a", notEqual)), left, right)
+		Expect(deltas).To(ContainElement(NewModified(Name("a"), left["a"], right["a"])))
+
+		deltas = compare(New(WithPathComparator("/b", custom)), left, right)
+		Expect(deltas).To(Equal([]Delta{NewModified(Name("b"), "old", "new")}))
+	})
+})
@@ -0,0 +1,102 @@
+package gojsondiff
+
+import (
+	"sync"
+)
+
+// WithConcurrency makes the Differ compare values of sibling objects and
+// arrays, and candidates of modified array elements, on up to the number of
+// goroutines for each call. The Deltas are the same as comparing sequentially,
+// unless WithMaxNodes or WithTimeBudget ends the comparison, since the
+// goroutines spend the budget in no particular order. Hooks and comparators
+// must be safe for concurrent use. Zero and one compare sequentially, which is
+// the default.
+func WithConcurrency(workers int) Option {
+	return func(differ *Differ) {
+		differ.concurrency = workers
+	}
+}
+
+// workers returns the tokens of the workers of the call, or nil when values
+// are compared sequentially.
+func (differ *Differ) workers() chan struct{} {
+	if differ.budget == nil {
+		return nil
+	}
+	return differ.budget.workers
+}
+
+// parallel calls fn for each index up to n, on idle workers of the call and
+// on the calling goroutine when no worker is idle, and returns when all the
+// calls return. Calls running on the calling goroutine can call parallel
+// again without waiting for workers.
+func (differ *Differ) parallel(n int, fn func(i int)) {
+	workers := differ.workers()
+	if workers == nil || n < 2 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case workers <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-workers
+					wg.Done()
+				}()
+				fn(i)
+			}(i)
+		default:
+			fn(i)
+		}
+	}
+	wg.Wait()
+}
+
+// compareFields compares the values of the names in both objects, and returns
+// the Deltas by the indexes of the names, which are nil for the same values
+// and names not in the right object. With workers, objects and arrays are
+// compared in parallel after the other values.
+func (differ *Differ) compareFields(
+	path []Position,
+	names []string,
+	left map[string]interface{},
+	right map[string]interface{},
+	policy *valuePolicy,
+) []Delta {
+	deltas := make([]Delta, len(names))
+	compare := func(i int) {
+		name := names[i]
+		if rightValue, ok := right[name]; ok {
+			if same, delta := differ.compareValues(path, Name(name), left[name], rightValue, policy.field(name)); !same {
+				deltas[i] = delta
+			}
+		}
+	}
+
+	if differ.workers() == nil {
+		for i := range names {
+			compare(i)
+		}
+		return deltas
+	}
+
+	nested := make([]int, 0)
+	for i, name := range names {
+		switch left[name].(type) {
+		case map[string]interface{}, *OrderedMap, []interface{}:
+			nested = append(nested, i)
+		default:
+			compare(i)
+		}
+	}
+	differ.parallel(len(nested), func(i int) {
+		compare(nested[i])
+	})
+	return deltas
+}
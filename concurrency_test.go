@@ -0,0 +1,82 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/yudai/gojsondiff/tests"
+
+	"context"
+	"fmt"
+	"testing"
+)
+
+// largeObject returns an object with sibling subtrees holding the arrays
+// returned by editedArray.
+func largeObject(subtrees, size int) (left, right map[string]interface{}) {
+	left, right = map[string]interface{}{}, map[string]interface{}{}
+	for i := 0; i < subtrees; i++ {
+		l, r := editedArray(size, int64(i))
+		name := fmt.Sprintf("tree%d", i)
+		left[name] = map[string]interface{}{"items": l, "id": float64(i)}
+		right[name] = map[string]interface{}{"items": r, "id": float64(i)}
+	}
+	return left, right
+}
+
+var _ = Describe("Concurrency", func() {
+	It("returns the same deltas as comparing sequentially", func() {
+		for _, pair := range fixturePairs {
+			left, right := LoadFixture(pair[0]), LoadFixture(pair[1])
+			expected := formatDelta(New().CompareObjects(left, right))
+			Expect(formatDelta(New(WithConcurrency(4)).CompareObjects(left, right))).To(Equal(expected), pair[0])
+		}
+
+		left, right := largeObject(8, 100)
+		for _, opts := range [][]Option{nil, {WithMoveSimilarityThreshold(0.5)}, {WithRenameDetection(0.5)}} {
+			expected := formatDelta(New(opts...).CompareObjects(left, right))
+			for _, workers := range []int{2, 4, 16} {
+				differ := New(opts...).With(WithConcurrency(workers))
+				Expect(formatDelta(differ.CompareObjects(left, right))).To(Equal(expected))
+			}
+		}
+
+		ordered, orderedChanged := NewOrderedMap(), NewOrderedMap()
+		for name, value := range left {
+			ordered.Set(name, value)
+			orderedChanged.Set(name, right[name])
+		}
+		expected := formatDelta(New().CompareOrderedObjects(ordered, orderedChanged))
+		Expect(formatDelta(New(WithConcurrency(4)).CompareOrderedObjects(ordered, orderedChanged))).To(Equal(expected))
+	})
+
+	It("honours contexts and limits", func() {
+		left, right := largeObject(4, 100)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := New(WithConcurrency(4)).CompareObjectsContext(ctx, left, right)
+		Expect(err).To(Equal(context.Canceled))
+
+		d := New(WithConcurrency(4), WithMaxNodes(50)).CompareObjects(left, right)
+		patched, _ := largeObject(4, 100)
+		New().ApplyPatch(patched, d)
+		Expect(patched).To(Equal(right))
+	})
+})
+
+func benchmarkCompareSubtrees(b *testing.B, differ *Differ) {
+	left, right := largeObject(16, 300)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		differ.CompareObjects(left, right)
+	}
+}
+
+func BenchmarkCompareSubtreesSequentially(b *testing.B) {
+	benchmarkCompareSubtrees(b, New())
+}
+
+func BenchmarkCompareSubtreesConcurrently(b *testing.B) {
+	benchmarkCompareSubtrees(b, New(WithConcurrency(8)))
+}
@@ -158,6 +158,10 @@ func (d *Object) PostApply(object interface{}) interface{} {
 		o := object.(map[string]interface{})
 		n := string(d.PostPosition().(Name))
 		o[n] = applyDeltas(d.Deltas, o[n])
+	case *OrderedMap:
+		o := object.(*OrderedMap)
+		n := string(d.PostPosition().(Name))
+		o.Set(n, applyDeltas(d.Deltas, o.values[n]))
 	case []interface{}:
 		o := object.([]interface{})
 		n := int(d.PostPosition().(Index))
@@ -193,6 +197,10 @@ func (d *Array) PostApply(object interface{}) interface{} {
 		o := object.(map[string]interface{})
 		n := string(d.PostPosition().(Name))
 		o[n] = applyDeltas(d.Deltas, o[n])
+	case *OrderedMap:
+		o := object.(*OrderedMap)
+		n := string(d.PostPosition().(Name))
+		o.Set(n, applyDeltas(d.Deltas, o.values[n]))
 	case []interface{}:
 		o := object.([]interface{})
 		n := int(d.PostPosition().(Index))
@@ -225,6 +233,8 @@ func (d *Added) PostApply(object interface{}) interface{} {
 	switch object.(type) {
 	case map[string]interface{}:
 		object.(map[string]interface{})[string(d.PostPosition().(Name))] = d.Value
+	case *OrderedMap:
+		object.(*OrderedMap).Set(string(d.PostPosition().(Name)), d.Value)
 	case []interface{}:
 		i := int(d.PostPosition().(Index))
 		o := object.([]interface{})
@@ -274,6 +284,8 @@ func (d *Modified) PostApply(object interface{}) interface{} {
 	case map[string]interface{}:
 		// TODO check old value
 		object.(map[string]interface{})[string(d.PostPosition().(Name))] = d.NewValue
+	case *OrderedMap:
+		object.(*OrderedMap).Set(string(d.PostPosition().(Name)), d.NewValue)
 	case []interface{}:
 		object.([]interface{})[int(d.PostPosition().(Index))] = d.NewValue
 	}
@@ -305,6 +317,9 @@ type TextDiff struct {
 
 	// Diff string
 	Diff []dmp.Patch
+
+	// Mode is the granularity of the Diff
+	Mode TextMode
 }
 
 // NewTextDiff returns
@@ -326,6 +341,13 @@ func (d *TextDiff) PostApply(object interface{}) interface{} {
 		// TODO error
 		d.patch()
 		o[i] = d.NewValue
+	case *OrderedMap:
+		o := object.(*OrderedMap)
+		i := string(d.PostPosition().(Name))
+		d.OldValue = o.values[i]
+		// TODO error
+		d.patch()
+		o.Set(i, d.NewValue)
 	case []interface{}:
 		o := object.([]interface{})
 		i := d.PostPosition().(Index)
@@ -380,6 +402,8 @@ func (d *Deleted) PreApply(object interface{}) interface{} {
 	case map[string]interface{}:
 		// TODO check old value
 		delete(object.(map[string]interface{}), string(d.PrePosition().(Name)))
+	case *OrderedMap:
+		object.(*OrderedMap).Delete(string(d.PrePosition().(Name)))
 	case []interface{}:
 		i := int(d.PrePosition().(Index))
 		o := object.([]interface{})
@@ -396,6 +420,8 @@ func (d Deleted) Similarity() (similarity float64) {
 // changed. Note that, in this library, assigning a Moved and a Modified to
 // a single position is not allowed. For the compatibility with jsondiffpatch,
 // the Moved in this library can hold the old and new value in it.
+// A Moved in an object represents a renamed key, which is an extension to
+// jsondiffpatch.
 type Moved struct {
 	preDelta
 	postDelta
@@ -420,7 +446,15 @@ func NewMoved(oldPosition Position, newPosition Position, value interface{}, del
 func (d *Moved) PreApply(object interface{}) interface{} {
 	switch object.(type) {
 	case map[string]interface{}:
-		//not supported
+		o := object.(map[string]interface{})
+		n := string(d.PrePosition().(Name))
+		d.Value = o[n]
+		delete(o, n)
+	case *OrderedMap:
+		o := object.(*OrderedMap)
+		n := string(d.PrePosition().(Name))
+		d.Value = o.values[n]
+		o.Delete(n)
 	case []interface{}:
 		i := int(d.PrePosition().(Index))
 		o := object.([]interface{})
@@ -433,7 +467,9 @@ func (d *Moved) PreApply(object interface{}) interface{} {
 func (d *Moved) PostApply(object interface{}) interface{} {
 	switch object.(type) {
 	case map[string]interface{}:
-		//not supported
+		object.(map[string]interface{})[string(d.PostPosition().(Name))] = d.Value
+	case *OrderedMap:
+		object.(*OrderedMap).Set(string(d.PostPosition().(Name)), d.Value)
 	case []interface{}:
 		i := int(d.PostPosition().(Index))
 		o := object.([]interface{})
@@ -452,6 +488,12 @@ func (d *Moved) PostApply(object interface{}) interface{} {
 
 func (d *Moved) similarity() (similarity float64) {
 	similarity = 0.6 // as type and contents are same
+	if _, renamed := d.PrePosition().(Name); renamed {
+		if delta, ok := d.Delta.(Delta); ok {
+			return similarity + 0.4*delta.Similarity()
+		}
+		return 1
+	}
 	ratio := float64(d.PrePosition().(Index)) / float64(d.PostPosition().(Index))
 	if ratio > 1 {
 		ratio = 1 / ratio
@@ -0,0 +1,154 @@
+package gojsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// An Embedded is a Modified of a string holding a JSON document, such as a
+// serialized configuration, with the Deltas between the documents. The
+// positions of the Deltas are relative to the root of the document.
+type Embedded struct {
+	Modified
+
+	// Deltas holds the Deltas between the documents
+	Deltas []Delta
+}
+
+// NewEmbedded returns an Embedded. The old and new values can be nil when
+// they are unknown, e.g. when the Embedded is unmarshaled.
+func NewEmbedded(position Position, deltas []Delta, oldValue, newValue interface{}) *Embedded {
+	d := Embedded{
+		Modified: *NewModified(position, oldValue, newValue),
+		Deltas:   deltas,
+	}
+	return &d
+}
+
+// PostApply patches the document in the string at the position. A value
+// which is not a string holding a JSON document is kept as is, since
+// ApplyPatch cannot report errors. ApplyPatchToValue reports such values.
+func (d *Embedded) PostApply(object interface{}) interface{} {
+	switch object.(type) {
+	case map[string]interface{}:
+		o := object.(map[string]interface{})
+		i := string(d.PostPosition().(Name))
+		if patched, err := d.patch(o[i]); err == nil {
+			o[i] = patched
+		}
+	case *OrderedMap:
+		o := object.(*OrderedMap)
+		i := string(d.PostPosition().(Name))
+		if patched, err := d.patch(o.values[i]); err == nil {
+			o.Set(i, patched)
+		}
+	case []interface{}:
+		o := object.([]interface{})
+		i := int(d.PostPosition().(Index))
+		if patched, err := d.patch(o[i]); err == nil {
+			o[i] = patched
+		}
+	}
+	return object
+}
+
+// patch applies the Deltas to the document in the string and serializes the
+// document again. The keys of objects keep their order.
+func (d *Embedded) patch(value interface{}) (string, error) {
+	text, ok := value.(string)
+	if !ok {
+		return "", errors.New("Embedded document is not a string")
+	}
+	document, err := UnmarshalOrdered([]byte(text))
+	if err != nil {
+		return "", err
+	}
+	document = applyDeltas(d.Deltas, document)
+
+	buffer := bytes.NewBuffer([]byte{})
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// WithEmbeddedJSON makes the Differ compare strings holding JSON objects or
+// arrays as documents. Modified documents are reported as Embedded, and
+// documents differing only in formatting or key order are the same.
+func WithEmbeddedJSON() Option {
+	return WithEmbeddedJSONAt("/**")
+}
+
+// WithEmbeddedJSONAt is WithEmbeddedJSON limited to strings at paths
+// matching the patterns, as in WithPathComparator.
+func WithEmbeddedJSONAt(patterns ...string) Option {
+	return func(differ *Differ) {
+		embedded := differ.embeddedJSON[:len(differ.embeddedJSON):len(differ.embeddedJSON)]
+		for _, pattern := range patterns {
+			embedded = append(embedded, parsePattern(pattern))
+		}
+		differ.embeddedJSON = embedded
+	}
+}
+
+func (differ *Differ) embeddedJSONAt(path []Position) bool {
+	for _, segments := range differ.embeddedJSON {
+		if matchPattern(segments, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareEmbedded compares the strings as JSON documents. It returns ok false
+// when either of them is not a JSON object or array, or they are of different
+// types.
+func (differ *Differ) compareEmbedded(path []Position, position Position, left, right string) (same bool, delta Delta, ok bool) {
+	leftDocument, ok := parseEmbedded(left)
+	if !ok {
+		return false, nil, false
+	}
+	rightDocument, ok := parseEmbedded(right)
+	if !ok {
+		return false, nil, false
+	}
+
+	valuePath := childPosition(path, position)
+	var deltas []Delta
+	switch l := leftDocument.(type) {
+	case *OrderedMap:
+		r, isObject := rightDocument.(*OrderedMap)
+		if !isObject {
+			return false, nil, false
+		}
+		deltas = differ.compareOrderedMaps(valuePath, l, r, nil)
+	case []interface{}:
+		r, isArray := rightDocument.([]interface{})
+		if !isArray {
+			return false, nil, false
+		}
+		deltas = differ.compareArrays(valuePath, l, r, nil)
+	}
+
+	if len(deltas) == 0 {
+		return true, nil, true
+	}
+	return false, NewEmbedded(position, deltas, left, right), true
+}
+
+// parseEmbedded parses a JSON object or array in the string.
+func parseEmbedded(text string) (document interface{}, ok bool) {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+	document, err := UnmarshalOrdered([]byte(trimmed))
+	if err != nil {
+		return nil, false
+	}
+	return document, true
+}
@@ -0,0 +1,93 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Embedded JSON", func() {
+	left := map[string]interface{}{
+		"config": `{"name": "api", "ports": [80, 443], "debug": false}`,
+		"note":   `{"a": 1}`,
+	}
+	right := map[string]interface{}{
+		"config": `{"name": "api", "ports": [80, 8443], "debug": true, "url": "a<b"}`,
+		"note":   `{"a": 2}`,
+	}
+
+	It("compares strings as documents", func() {
+		d := New(WithEmbeddedJSON()).CompareObjects(left, right)
+		Expect(d.Deltas()).To(HaveLen(2))
+		for _, delta := range d.Deltas() {
+			Expect(delta).To(BeAssignableToTypeOf(&Embedded{}))
+		}
+
+		embedded := d.Deltas()[0].(*Embedded)
+		if embedded.Position != Name("config") {
+			embedded = d.Deltas()[1].(*Embedded)
+		}
+		Expect(embedded.OldValue).To(Equal(left["config"]))
+		Expect(embedded.Deltas).To(ContainElement(NewModified(Name("debug"), false, true)))
+		Expect(embedded.Deltas).To(ContainElement(NewAdded(Name("url"), "a<b")))
+	})
+
+	It("limits documents to paths", func() {
+		d := New(WithEmbeddedJSONAt("/note")).CompareObjects(left, right)
+		for _, delta := range d.Deltas() {
+			_, isEmbedded := delta.(*Embedded)
+			Expect(isEmbedded).To(Equal(delta.(PostDelta).PostPosition() == Name("note")))
+		}
+	})
+
+	It("ignores formatting and key order", func() {
+		d := New(WithEmbeddedJSON()).CompareObjects(
+			map[string]interface{}{"a": `{"x": 1, "y": [true]}`},
+			map[string]interface{}{"a": "{\n  \"y\": [true],\n  \"x\": 1\n}"},
+		)
+		Expect(d.Modified()).To(BeFalse())
+	})
+
+	It("falls back to strings which are not documents", func() {
+		d := New(WithEmbeddedJSON()).CompareObjects(
+			map[string]interface{}{"a": `{"x": 1}`, "b": "plain"},
+			map[string]interface{}{"a": `[1]`, "b": "text"},
+		)
+		for _, delta := range d.Deltas() {
+			Expect(delta).To(BeAssignableToTypeOf(&Modified{}))
+		}
+	})
+
+	It("patches documents by serializing them", func() {
+		d := New(WithEmbeddedJSON()).CompareObjects(left, right)
+		patched := map[string]interface{}{"config": left["config"], "note": left["note"]}
+		New().ApplyPatch(patched, d)
+		Expect(patched).To(Equal(map[string]interface{}{
+			"config": `{"name":"api","ports":[80,8443],"debug":true,"url":"a<b"}`,
+			"note":   `{"a":2}`,
+		}))
+
+		type config struct {
+			Config string `json:"config"`
+			Note   string `json:"note"`
+		}
+		value := config{Config: left["config"].(string), Note: left["note"].(string)}
+		Expect(New().ApplyPatchToValue(&value, d)).To(Succeed())
+		Expect(value.Note).To(Equal(`{"a":2}`))
+		Expect(value.Config).To(Equal(patched["config"]))
+	})
+
+	It("keeps values which are not documents", func() {
+		d := New(WithEmbeddedJSON()).CompareObjects(left, right)
+		patched := map[string]interface{}{"config": "not a document", "note": left["note"]}
+		New().ApplyPatch(patched, d)
+		Expect(patched["config"]).To(Equal("not a document"))
+		Expect(patched["note"]).To(Equal(`{"a":2}`))
+
+		value := map[string]interface{}{"config": "not a document", "note": left["note"]}
+		err := New().ApplyPatchToValue(&value, d)
+		Expect(err).To(BeAssignableToTypeOf(&PatchError{}))
+		Expect(err.(*PatchError).Path).To(Equal([]Position{Name("config")}))
+	})
+})
@@ -1,11 +1,15 @@
 package formatter
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"sort"
+	"strings"
 
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
 	diff "github.com/yudai/gojsondiff"
 )
 
@@ -19,16 +23,23 @@ func NewAsciiFormatter(left interface{}, config AsciiFormatterConfig) *AsciiForm
 type AsciiFormatter struct {
 	left    interface{}
 	config  AsciiFormatterConfig
-	buffer  *bytes.Buffer
+	writer  *bufio.Writer
 	path    []string
 	size    []int
 	inArray []bool
 	line    *AsciiLine
+	// movedName is the name of the moved item being printed
+	movedName string
+	moved     *diff.Moved
+	// emit receives closed lines instead of the writer when set
+	emit func(line *AsciiLine)
 }
 
 type AsciiFormatterConfig struct {
 	ShowArrayIndex bool
 	Coloring       bool
+	// WordDiff highlights changes in long texts by words instead of characters
+	WordDiff bool
 }
 
 var AsciiFormatterDefaultConfig = AsciiFormatterConfig{}
@@ -37,30 +48,43 @@ type AsciiLine struct {
 	marker string
 	indent int
 	buffer *bytes.Buffer
+	// key is the key printed at the start of the buffer
+	key string
+	// movedKeys are the keys of a moved item before and after the move
+	movedKeys []string
 }
 
 func (f *AsciiFormatter) Format(diff diff.Diff) (result string, err error) {
-	f.buffer = bytes.NewBuffer([]byte{})
+	buffer := bytes.NewBuffer([]byte{})
+	err = f.FormatTo(buffer, diff)
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (f *AsciiFormatter) FormatTo(w io.Writer, diff diff.Diff) error {
+	f.writer = bufio.NewWriter(w)
 	f.path = []string{}
 	f.size = []int{}
 	f.inArray = []bool{}
 
-	if v, ok := f.left.(map[string]interface{}); ok {
-		f.formatObject(v, diff)
+	if keys, values, ok := objectEntries(f.left); ok {
+		f.formatObject(keys, values, diff)
 	} else if v, ok := f.left.([]interface{}); ok {
 		f.formatArray(v, diff)
 	} else {
-		return "", fmt.Errorf("expected map[string]interface{} or []interface{}, got %T",
+		return fmt.Errorf("expected map[string]interface{}, *OrderedMap or []interface{}, got %T",
 			f.left)
 	}
 
-	return f.buffer.String(), nil
+	return f.writer.Flush()
 }
 
-func (f *AsciiFormatter) formatObject(left map[string]interface{}, df diff.Diff) {
+func (f *AsciiFormatter) formatObject(keys []string, left map[string]interface{}, df diff.Diff) {
 	f.addLineWith(AsciiSame, "{")
 	f.push("ROOT", len(left), false)
-	f.processObject(left, df.Deltas())
+	f.processObject(keys, left, df.Deltas())
 	f.pop()
 	f.addLineWith(AsciiSame, "}")
 }
@@ -96,8 +120,7 @@ func (f *AsciiFormatter) processArray(array []interface{}, deltas []diff.Delta)
 	return nil
 }
 
-func (f *AsciiFormatter) processObject(object map[string]interface{}, deltas []diff.Delta) error {
-	names := sortedKeys(object)
+func (f *AsciiFormatter) processObject(names []string, object map[string]interface{}, deltas []diff.Delta) error {
 	for _, name := range names {
 		value := object[name]
 		f.processItem(value, deltas, diff.Name(name))
@@ -120,79 +143,9 @@ func (f *AsciiFormatter) processItem(value interface{}, deltas []diff.Delta, pos
 	positionStr := position.String()
 	if len(matchedDeltas) > 0 {
 		for _, matchedDelta := range matchedDeltas {
-
-			switch matchedDelta.(type) {
-			case *diff.Object:
-				d := matchedDelta.(*diff.Object)
-				switch value.(type) {
-				case map[string]interface{}:
-					//ok
-				default:
-					return errors.New("Type mismatch")
-				}
-				o := value.(map[string]interface{})
-
-				f.newLine(AsciiSame)
-				f.printKey(positionStr)
-				f.print("{")
-				f.closeLine()
-				f.push(positionStr, len(o), false)
-				f.processObject(o, d.Deltas)
-				f.pop()
-				f.newLine(AsciiSame)
-				f.print("}")
-				f.printComma()
-				f.closeLine()
-
-			case *diff.Array:
-				d := matchedDelta.(*diff.Array)
-				switch value.(type) {
-				case []interface{}:
-					//ok
-				default:
-					return errors.New("Type mismatch")
-				}
-				a := value.([]interface{})
-
-				f.newLine(AsciiSame)
-				f.printKey(positionStr)
-				f.print("[")
-				f.closeLine()
-				f.push(positionStr, len(a), true)
-				f.processArray(a, d.Deltas)
-				f.pop()
-				f.newLine(AsciiSame)
-				f.print("]")
-				f.printComma()
-				f.closeLine()
-
-			case *diff.Added:
-				d := matchedDelta.(*diff.Added)
-				f.printRecursive(positionStr, d.Value, AsciiAdded)
-				f.size[len(f.size)-1]++
-
-			case *diff.Modified:
-				d := matchedDelta.(*diff.Modified)
-				savedSize := f.size[len(f.size)-1]
-				f.printRecursive(positionStr, d.OldValue, AsciiDeleted)
-				f.size[len(f.size)-1] = savedSize
-				f.printRecursive(positionStr, d.NewValue, AsciiAdded)
-
-			case *diff.TextDiff:
-				savedSize := f.size[len(f.size)-1]
-				d := matchedDelta.(*diff.TextDiff)
-				f.printRecursive(positionStr, d.OldValue, AsciiDeleted)
-				f.size[len(f.size)-1] = savedSize
-				f.printRecursive(positionStr, d.NewValue, AsciiAdded)
-
-			case *diff.Deleted:
-				d := matchedDelta.(*diff.Deleted)
-				f.printRecursive(positionStr, d.Value, AsciiDeleted)
-
-			default:
-				return errors.New("Unknown Delta type detected")
+			if err := f.processDelta(positionStr, position, value, matchedDelta); err != nil {
+				return err
 			}
-
 		}
 	} else {
 		f.printRecursive(positionStr, value, AsciiSame)
@@ -201,10 +154,145 @@ func (f *AsciiFormatter) processItem(value interface{}, deltas []diff.Delta, pos
 	return nil
 }
 
+// processDelta prints the value at the position with the changes of the
+// Delta, named by the name.
+func (f *AsciiFormatter) processDelta(name string, position diff.Position, value interface{}, delta diff.Delta) error {
+	switch delta.(type) {
+	case *diff.Object, *diff.Array:
+		if err := f.printNested(name, value, delta, AsciiSame); err != nil {
+			return err
+		}
+
+	case *diff.Added:
+		d := delta.(*diff.Added)
+		f.printRecursive(name, d.Value, AsciiAdded)
+		f.size[len(f.size)-1]++
+
+	case *diff.Modified:
+		d := delta.(*diff.Modified)
+		savedSize := f.size[len(f.size)-1]
+		f.printRecursive(name, d.OldValue, AsciiDeleted)
+		f.size[len(f.size)-1] = savedSize
+		f.printRecursive(name, d.NewValue, AsciiAdded)
+
+	case *diff.TextDiff:
+		savedSize := f.size[len(f.size)-1]
+		d := delta.(*diff.TextDiff)
+		oldText, newText, ok := textDiffValues(value, d)
+		if !ok {
+			f.printRecursive(name, d.OldValue, AsciiDeleted)
+			f.size[len(f.size)-1] = savedSize
+			f.printRecursive(name, d.NewValue, AsciiAdded)
+			break
+		}
+		if d.Mode == diff.TextByLines {
+			f.printLineDiff(name, diff.DiffTexts(oldText, newText, diff.TextByLines))
+			break
+		}
+		diffs := inlineDiff(oldText, newText, f.config.WordDiff || d.Mode == diff.TextByWords)
+		f.printTextDiff(name, diffs, AsciiDeleted)
+		f.size[len(f.size)-1] = savedSize
+		f.printTextDiff(name, diffs, AsciiAdded)
+
+	case *diff.Embedded:
+		d := delta.(*diff.Embedded)
+		document, documentDelta, ok := embeddedDocument(value, d)
+		if !ok {
+			savedSize := f.size[len(f.size)-1]
+			f.printRecursive(name, d.OldValue, AsciiDeleted)
+			f.size[len(f.size)-1] = savedSize
+			f.printRecursive(name, d.NewValue, AsciiAdded)
+			break
+		}
+		f.processItem(document, []diff.Delta{documentDelta}, position)
+	case *diff.Moved:
+		d := delta.(*diff.Moved)
+		name = d.PrePosition().String() + " -> " + d.PostPosition().String()
+		if _, renamed := d.PostPosition().(diff.Name); renamed {
+			// printed as "old" -> "new"
+			name = d.PrePosition().String() + `" -> "` + d.PostPosition().String()
+		}
+		savedName, savedMoved := f.movedName, f.moved
+		f.movedName, f.moved = name, d
+		defer func() { f.movedName, f.moved = savedName, savedMoved }()
+		switch d.Delta.(type) {
+		case nil:
+			f.printRecursive(name, value, AsciiMoved)
+		case *diff.Object, *diff.Array:
+			if err := f.printNested(name, value, d.Delta.(diff.Delta), AsciiMoved); err != nil {
+				return err
+			}
+		default:
+			// changed values are printed as changed under the moved name
+			return f.processDelta(name, position, value, d.Delta.(diff.Delta))
+		}
+
+	case *diff.Deleted:
+		d := delta.(*diff.Deleted)
+		f.printRecursive(name, d.Value, AsciiDeleted)
+
+	default:
+		return errors.New("Unknown Delta type detected")
+	}
+
+	return nil
+}
+
+// printNested prints the object or array value with the changes of the
+// Object or Array Delta. The brackets are printed with the marker.
+func (f *AsciiFormatter) printNested(name string, value interface{}, delta diff.Delta, marker string) error {
+	switch d := delta.(type) {
+	case *diff.Object:
+		keys, o, ok := objectEntries(value)
+		if !ok {
+			return errors.New("Type mismatch")
+		}
+
+		f.newLine(marker)
+		f.printKey(name)
+		f.print("{")
+		f.closeLine()
+		f.push(name, len(o), false)
+		f.processObject(keys, o, d.Deltas)
+		f.pop()
+		f.newLine(marker)
+		f.print("}")
+		f.printComma()
+		f.closeLine()
+
+	case *diff.Array:
+		a, ok := value.([]interface{})
+		if !ok {
+			return errors.New("Type mismatch")
+		}
+
+		f.newLine(marker)
+		f.printKey(name)
+		f.print("[")
+		f.closeLine()
+		f.push(name, len(a), true)
+		f.processArray(a, d.Deltas)
+		f.pop()
+		f.newLine(marker)
+		f.print("]")
+		f.printComma()
+		f.closeLine()
+
+	default:
+		return errors.New("Unknown Delta type detected")
+	}
+	return nil
+}
+
 func (f *AsciiFormatter) searchDeltas(deltas []diff.Delta, position diff.Position) (results []diff.Delta) {
 	results = make([]diff.Delta, 0)
 	for _, delta := range deltas {
 		switch delta.(type) {
+		case *diff.Moved:
+			// shown at the original position
+			if delta.(*diff.Moved).PrePosition() == position {
+				results = append(results, delta)
+			}
 		case diff.PostDelta:
 			if delta.(diff.PostDelta).PostPosition() == position {
 				results = append(results, delta)
@@ -224,11 +312,26 @@ const (
 	AsciiSame    = " "
 	AsciiAdded   = "+"
 	AsciiDeleted = "-"
+	AsciiMoved   = "~"
 )
 
 var AsciiStyles = map[string]string{
 	AsciiAdded:   "30;42",
 	AsciiDeleted: "30;41",
+	AsciiMoved:   "30;43",
+}
+
+// AsciiInlineStyles are styles for changed parts of texts.
+// They are used inside lines and must not reset the style of lines.
+var AsciiInlineStyles = map[string][2]string{
+	AsciiAdded:   {"\x1b[1;4m", "\x1b[22;24m"},
+	AsciiDeleted: {"\x1b[1;4m", "\x1b[22;24m"},
+}
+
+// AsciiInlineMarkers enclose changed parts of texts when coloring is disabled.
+var AsciiInlineMarkers = map[string][2]string{
+	AsciiAdded:   {"{+", "+}"},
+	AsciiDeleted: {"[-", "-]"},
 }
 
 func (f *AsciiFormatter) push(name string, size int, array bool) {
@@ -261,30 +364,45 @@ func (f *AsciiFormatter) newLine(marker string) {
 }
 
 func (f *AsciiFormatter) closeLine() {
+	if f.emit != nil {
+		f.emit(f.line)
+		return
+	}
+
 	style, ok := AsciiStyles[f.line.marker]
 	if f.config.Coloring && ok {
-		f.buffer.WriteString("\x1b[" + style + "m")
+		f.writer.WriteString("\x1b[" + style + "m")
 	}
 
-	f.buffer.WriteString(f.line.marker)
+	f.writer.WriteString(f.line.marker)
 	for n := 0; n < f.line.indent; n++ {
-		f.buffer.WriteString("  ")
+		f.writer.WriteString("  ")
 	}
-	f.buffer.Write(f.line.buffer.Bytes())
+	f.writer.Write(f.line.buffer.Bytes())
 
 	if f.config.Coloring && ok {
-		f.buffer.WriteString("\x1b[0m")
+		f.writer.WriteString("\x1b[0m")
 	}
 
-	f.buffer.WriteRune('\n')
+	f.writer.WriteRune('\n')
 }
 
 func (f *AsciiFormatter) printKey(name string) {
-	if !f.inArray[len(f.inArray)-1] {
-		fmt.Fprintf(f.line.buffer, `"%s": `, name)
-	} else if f.config.ShowArrayIndex {
-		fmt.Fprintf(f.line.buffer, `%s: `, name)
+	inArray := f.inArray[len(f.inArray)-1]
+	if !inArray {
+		f.line.key = fmt.Sprintf(`"%s": `, name)
+	} else if f.config.ShowArrayIndex || ((f.line.marker == AsciiMoved || name == f.movedName) && name != "") {
+		f.line.key = fmt.Sprintf(`%s: `, name)
+	}
+	if name != "" && name == f.movedName {
+		pre, post := f.moved.PrePosition().String(), f.moved.PostPosition().String()
+		if inArray {
+			f.line.movedKeys = []string{pre + ": ", post + ": "}
+		} else {
+			f.line.movedKeys = []string{`"` + pre + `": `, `"` + post + `": `}
+		}
 	}
+	f.line.buffer.WriteString(f.line.key)
 }
 
 func (f *AsciiFormatter) printComma() {
@@ -309,19 +427,74 @@ func (f *AsciiFormatter) print(a string) {
 	f.line.buffer.WriteString(a)
 }
 
+// printTextDiff prints the old text with marker AsciiDeleted or the new text
+// with marker AsciiAdded, highlighting the changed parts.
+func (f *AsciiFormatter) printTextDiff(name string, diffs []dmp.Diff, marker string) {
+	skip := dmp.DiffInsert
+	if marker == AsciiAdded {
+		skip = dmp.DiffDelete
+	}
+	open, close := AsciiInlineMarkers[marker][0], AsciiInlineMarkers[marker][1]
+	if f.config.Coloring {
+		open, close = AsciiInlineStyles[marker][0], AsciiInlineStyles[marker][1]
+	}
+
+	f.newLine(marker)
+	f.printKey(name)
+	f.print(`"`)
+	for _, d := range diffs {
+		switch d.Type {
+		case skip:
+		case dmp.DiffEqual:
+			f.print(d.Text)
+		default:
+			f.print(open + d.Text + close)
+		}
+	}
+	f.print(`"`)
+	f.printComma()
+	f.closeLine()
+}
+
+// printLineDiff prints a text diffed by lines as a block between triple
+// quotes, with each line of the texts marked as same, deleted or added.
+func (f *AsciiFormatter) printLineDiff(name string, diffs []dmp.Diff) {
+	f.newLine(AsciiSame)
+	f.printKey(name)
+	f.print(`"""`)
+	f.closeLine()
+	f.push(name, 0, false)
+	for _, d := range diffs {
+		marker := AsciiSame
+		switch d.Type {
+		case dmp.DiffDelete:
+			marker = AsciiDeleted
+		case dmp.DiffInsert:
+			marker = AsciiAdded
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line != "" {
+				f.addLineWith(marker, strings.TrimSuffix(line, "\n"))
+			}
+		}
+	}
+	f.pop()
+	f.newLine(AsciiSame)
+	f.print(`"""`)
+	f.printComma()
+	f.closeLine()
+}
+
 func (f *AsciiFormatter) printRecursive(name string, value interface{}, marker string) {
-	switch value.(type) {
-	case map[string]interface{}:
+	if keys, m, ok := objectEntries(value); ok {
 		f.newLine(marker)
 		f.printKey(name)
 		f.print("{")
 		f.closeLine()
 
-		m := value.(map[string]interface{})
 		size := len(m)
 		f.push(name, size, false)
 
-		keys := sortedKeys(m)
 		for _, key := range keys {
 			f.printRecursive(key, m[key], marker)
 		}
@@ -331,7 +504,10 @@ func (f *AsciiFormatter) printRecursive(name string, value interface{}, marker s
 		f.print("}")
 		f.printComma()
 		f.closeLine()
+		return
+	}
 
+	switch value.(type) {
 	case []interface{}:
 		f.newLine(marker)
 		f.printKey(name)
@@ -360,6 +536,18 @@ func (f *AsciiFormatter) printRecursive(name string, value interface{}, marker s
 	}
 }
 
+// objectEntries returns the keys of a JSON object in the order to print them
+// and the values. Keys of *OrderedMap are kept in the order.
+func objectEntries(value interface{}) (keys []string, values map[string]interface{}, ok bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return sortedKeys(v), v, true
+	case *diff.OrderedMap:
+		return v.Keys(), v.Values(), true
+	}
+	return nil, nil, false
+}
+
 func sortedKeys(m map[string]interface{}) (keys []string) {
 	keys = make([]string, 0, len(m))
 	for key := range m {
@@ -117,6 +117,217 @@ var _ = Describe("Ascii", func() {
 			),
 			)
 		})
+
+		It("Prints moved items with their source and destination", func() {
+			a = LoadFixture("../FIXTURES/move_from.json")
+			b = LoadFixture("../FIXTURES/move_to.json")
+
+			diff := diff.New().CompareObjects(a, b)
+
+			f := NewAsciiFormatter(a, AsciiFormatterDefaultConfig)
+			deltaJson, err := f.Format(diff)
+			Expect(err).To(BeNil())
+			Expect(deltaJson).To(Equal(
+				` {
+   "arr": [
+     3,
+     5,
+     7,
+~    3 -> 1: 9,
+     11,
+~    5 -> 3: 13
+   ]
+ }
+`,
+			),
+			)
+		})
+
+		It("Highlights changed parts of long texts", func() {
+			a = LoadFixture("../FIXTURES/long_text_from.json")
+			b = LoadFixture("../FIXTURES/long_text_to.json")
+
+			diff := diff.New().CompareObjects(a, b)
+
+			f := NewAsciiFormatter(a, AsciiFormatterDefaultConfig)
+			deltaJson, err := f.Format(diff)
+			Expect(err).To(BeNil())
+			Expect(deltaJson).To(Equal(
+				` {
+-  "str": "aaefijaeufq2093jfaunfa;oij40fj[-q048hf-]bgvz;lxkcmz;ldkjfm48q9p8qfh[-qn4gbqqq4qp-]94hqnfaaso;dfj3n"
++  "str": "aaefijaeufq2093jfaunfa;oij40fj{+nafefea+}bgvz;lxkcmz;ldkjfm48q{+a3+}9p8qfh{+nafe+}94hqnfaaso;dfj3n"
+ }
+`,
+			),
+			)
+
+			f = NewAsciiFormatter(a, AsciiFormatterConfig{WordDiff: true})
+			deltaJson, err = f.Format(diff)
+			Expect(err).To(BeNil())
+			Expect(deltaJson).To(Equal(
+				` {
+-  "str": "aaefijaeufq2093jfaunfa;[-oij40fjq048hfbgvz-];lxkcmz;[-ldkjfm48q9p8qfhqn4gbqqq4qp94hqnfaaso-];dfj3n"
++  "str": "aaefijaeufq2093jfaunfa;{+oij40fjnafefeabgvz+};lxkcmz;{+ldkjfm48qa39p8qfhnafe94hqnfaaso+};dfj3n"
+ }
+`,
+			),
+			)
+		})
+
+		It("Highlights texts patched by unmarshaled deltas", func() {
+			a = LoadFixture("../FIXTURES/long_text_from.json")
+			b = LoadFixture("../FIXTURES/long_text_to.json")
+
+			deltaString, err := NewDeltaFormatter().Format(diff.New().CompareObjects(a, b))
+			Expect(err).To(BeNil())
+			unmarshaled, err := diff.NewUnmarshaller().UnmarshalString(deltaString)
+			Expect(err).To(BeNil())
+
+			f := NewAsciiFormatter(a, AsciiFormatterConfig{Coloring: true})
+			result, err := f.Format(unmarshaled)
+			Expect(err).To(BeNil())
+			Expect(result).To(ContainSubstring("oij40fj\x1b[1;4mq048hf\x1b[22;24mbgvz"))
+			Expect(result).To(ContainSubstring("oij40fj\x1b[1;4mnafefea\x1b[22;24mbgvz"))
+		})
+
+		It("Prints JSON documents in strings as objects", func() {
+			left := map[string]interface{}{"config": `{"name": "api", "debug": false}`}
+			right := map[string]interface{}{"config": `{"name": "api", "debug": true}`}
+			d := diff.New(diff.WithEmbeddedJSON()).CompareObjects(left, right)
+
+			result, err := NewAsciiFormatter(left, AsciiFormatterConfig{}).Format(d)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				` {
+   "config": {
+     "name": "api",
+-    "debug": false
++    "debug": true
+   }
+ }
+`,
+			))
+		})
+
+		It("Prints texts diffed by lines as blocks", func() {
+			left := map[string]interface{}{"query": "SELECT *\nFROM users\nWHERE id = 1\n", "n": 1.0}
+			right := map[string]interface{}{"query": "SELECT *\nFROM accounts\nWHERE id = 1\n", "n": 1.0}
+			d := diff.New(diff.WithTextMode(diff.TextAuto)).CompareObjects(left, right)
+
+			result, err := NewAsciiFormatter(left, AsciiFormatterConfig{}).Format(d)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				` {
+   "n": 1,
+   "query": """
+     SELECT *
+-    FROM users
++    FROM accounts
+     WHERE id = 1
+   """
+ }
+`,
+			))
+		})
+
+		It("Prints renamed keys", func() {
+			left := map[string]interface{}{"userName": "alice", "age": 20.0}
+			right := map[string]interface{}{"user_name": "alice", "age": 20.0}
+			d := diff.New(diff.WithRenameDetection(1)).CompareObjects(left, right)
+
+			result, err := NewAsciiFormatter(left, AsciiFormatterConfig{}).Format(d)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				` {
+   "age": 20,
+~  "userName" -> "user_name": "alice"
+ }
+`,
+			))
+		})
+
+		It("Prints changes of moved items", func() {
+			left := map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"name": "a", "kind": "item"},
+				map[string]interface{}{"name": "b", "kind": "item"},
+				map[string]interface{}{"name": "c", "kind": "item"},
+			}}
+			right := map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"name": "C", "kind": "item"},
+				map[string]interface{}{"name": "a", "kind": "item"},
+				map[string]interface{}{"name": "b", "kind": "item"},
+			}}
+			d := diff.New(diff.WithMoveSimilarityThreshold(0.5)).CompareObjects(left, right)
+
+			result, err := NewAsciiFormatter(left, AsciiFormatterConfig{}).Format(d)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				` {
+   "items": [
+     {
+       "kind": "item",
+       "name": "a"
+     },
+     {
+       "kind": "item",
+       "name": "b"
+     },
+~    2 -> 0: {
+       "kind": "item",
+-      "name": "c"
++      "name": "C"
+~    }
+   ]
+ }
+`,
+			))
+		})
+
+		It("Prints modified values of moved items", func() {
+			left := map[string]interface{}{"items": []interface{}{"a", "b", "c"}}
+			d := diff.NewDiff([]diff.Delta{diff.NewArray(diff.Name("items"), []diff.Delta{
+				diff.NewMoved(diff.Index(2), diff.Index(0), "c", diff.NewModified(diff.Index(0), "c", "C")),
+			})})
+
+			result, err := NewAsciiFormatter(left, AsciiFormatterConfig{}).Format(d)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				` {
+   "items": [
+     "a",
+     "b",
+-    2 -> 0: "c"
++    2 -> 0: "C"
+   ]
+ }
+`,
+			))
+		})
+
+		It("Prints keys of ordered objects in their order", func() {
+			differ := diff.New()
+			differ.PreserveKeyOrder = true
+			left := []byte(`{"zeta": 1, "alpha": {"y": true, "x": "a"}}`)
+			d, err := differ.Compare(left, []byte(`{"zeta": 2, "alpha": {"y": true, "x": "b"}}`))
+			Expect(err).To(BeNil())
+			ordered, err := differ.Unmarshal(left)
+			Expect(err).To(BeNil())
+
+			result, err := NewAsciiFormatter(ordered, AsciiFormatterConfig{}).Format(d)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				` {
+-  "zeta": 1,
++  "zeta": 2,
+   "alpha": {
+     "y": true,
+-    "x": "a"
++    "x": "b"
+   }
+ }
+`,
+			))
+		})
 	})
 
 })
@@ -0,0 +1,142 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeDeleted  = "deleted"
+	ChangeModified = "modified"
+	ChangeTextDiff = "textdiff"
+	ChangeMoved    = "moved"
+)
+
+// A Change is a flattened leaf Delta with its full path from the root.
+type Change struct {
+	// Path is the position of the changed value from the root.
+	// For moved values, this is the position before moving.
+	Path []diff.Position
+	// Kind is one of ChangeAdded, ChangeDeleted, ChangeModified,
+	// ChangeTextDiff and ChangeMoved
+	Kind string
+
+	// OldValue holds the value before the change, if available
+	OldValue interface{}
+	// NewValue holds the value after the change, if available
+	NewValue interface{}
+	// TextPatch holds the patch text of a text diff
+	TextPatch string
+	// MovedTo is the new position of a moved value
+	MovedTo diff.Position
+}
+
+// CollectChanges flattens all Deltas in the Diff into Changes in the order of
+// appearance.
+func CollectChanges(d diff.Diff) []Change {
+	return collectChanges([]diff.Position{}, d.Deltas(), []Change{})
+}
+
+// DeltaChanges flattens the Delta into Changes. The path is the position of
+// the parent of the Delta, as given to a diff.DeltaHandler.
+func DeltaChanges(path []diff.Position, delta diff.Delta) []Change {
+	return collectChanges(path, []diff.Delta{delta}, []Change{})
+}
+
+func collectChanges(path []diff.Position, deltas []diff.Delta, changes []Change) []Change {
+	for _, delta := range deltas {
+		switch delta.(type) {
+		case *diff.Object:
+			d := delta.(*diff.Object)
+			changes = collectChanges(childPath(path, d.Position), d.Deltas, changes)
+		case *diff.Array:
+			d := delta.(*diff.Array)
+			changes = collectChanges(childPath(path, d.Position), d.Deltas, changes)
+		case *diff.Added:
+			d := delta.(*diff.Added)
+			changes = append(changes, Change{
+				Path:     childPath(path, d.PostPosition()),
+				Kind:     ChangeAdded,
+				NewValue: d.Value,
+			})
+		case *diff.Modified:
+			d := delta.(*diff.Modified)
+			changes = append(changes, Change{
+				Path:     childPath(path, d.PostPosition()),
+				Kind:     ChangeModified,
+				OldValue: d.OldValue,
+				NewValue: d.NewValue,
+			})
+		case *diff.TextDiff:
+			d := delta.(*diff.TextDiff)
+			changes = append(changes, Change{
+				Path:      childPath(path, d.PostPosition()),
+				Kind:      ChangeTextDiff,
+				OldValue:  d.OldValue,
+				NewValue:  d.NewValue,
+				TextPatch: d.DiffString(),
+			})
+		case *diff.Embedded:
+			d := delta.(*diff.Embedded)
+			changes = collectChanges(childPath(path, d.Position), d.Deltas, changes)
+		case *diff.Deleted:
+			d := delta.(*diff.Deleted)
+			changes = append(changes, Change{
+				Path:     childPath(path, d.PrePosition()),
+				Kind:     ChangeDeleted,
+				OldValue: d.Value,
+			})
+		case *diff.Moved:
+			d := delta.(*diff.Moved)
+			changes = append(changes, Change{
+				Path:     childPath(path, d.PrePosition()),
+				Kind:     ChangeMoved,
+				OldValue: d.Value,
+				MovedTo:  d.PostPosition(),
+			})
+			if nested, ok := d.Delta.(diff.Delta); ok {
+				changes = collectChanges(path, []diff.Delta{nested}, changes)
+			}
+		}
+	}
+	return changes
+}
+
+func childPath(path []diff.Position, position diff.Position) []diff.Position {
+	child := make([]diff.Position, len(path), len(path)+1)
+	copy(child, path)
+	return append(child, position)
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// PathString returns the path in the dot notation, e.g. `spec.items[2].name`.
+func (c Change) PathString() string {
+	return PathString(c.Path)
+}
+
+// PathString returns a path in the dot notation, e.g. `spec.items[2].name`.
+func PathString(path []diff.Position) string {
+	buffer := bytes.NewBuffer([]byte{})
+	for _, position := range path {
+		switch position.(type) {
+		case diff.Index:
+			fmt.Fprintf(buffer, "[%d]", int(position.(diff.Index)))
+		default:
+			name := position.String()
+			if !identifierPattern.MatchString(name) {
+				fmt.Fprintf(buffer, "[%q]", name)
+				continue
+			}
+			if buffer.Len() > 0 {
+				buffer.WriteRune('.')
+			}
+			buffer.WriteString(name)
+		}
+	}
+	return buffer.String()
+}
@@ -1,9 +1,11 @@
 package formatter
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 
 	diff "github.com/yudai/gojsondiff"
 )
@@ -12,6 +14,7 @@ const (
 	DeltaDelete   = 0
 	DeltaTextDiff = 2
 	DeltaMove     = 3
+	DeltaEmbedded = 4
 )
 
 func NewDeltaFormatter() *DeltaFormatter {
@@ -25,27 +28,49 @@ type DeltaFormatter struct {
 }
 
 func (f *DeltaFormatter) Format(diff diff.Diff) (result string, err error) {
-	jsonObject, err := f.formatObject(diff.Deltas())
+	buffer := bytes.NewBuffer([]byte{})
+	err = f.FormatTo(buffer, diff)
 	if err != nil {
 		return "", err
 	}
-	var resultBytes []byte
-	if f.PrintIndent {
-		resultBytes, err = json.MarshalIndent(jsonObject, "", "  ")
-	} else {
-		resultBytes, err = json.Marshal(jsonObject)
-	}
+	return buffer.String(), nil
+}
+
+func (f *DeltaFormatter) FormatTo(w io.Writer, diff diff.Diff) error {
+	jsonObject, err := f.FormatAsJson(diff)
 	if err != nil {
-		return "", err
+		return err
 	}
-
-	return string(resultBytes) + "\n", nil
+	encoder := json.NewEncoder(w)
+	if f.PrintIndent {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(jsonObject)
 }
 
 func (f *DeltaFormatter) FormatAsJson(diff diff.Diff) (json map[string]interface{}, err error) {
+	if isArrayDiff(diff) {
+		return f.formatArray(diff.Deltas())
+	}
 	return f.formatObject(diff.Deltas())
 }
 
+// isArrayDiff returns true when the Diff is generated from arrays
+func isArrayDiff(d diff.Diff) bool {
+	for _, delta := range d.Deltas() {
+		var position diff.Position
+		switch delta.(type) {
+		case diff.PostDelta:
+			position = delta.(diff.PostDelta).PostPosition()
+		case diff.PreDelta:
+			position = delta.(diff.PreDelta).PrePosition()
+		}
+		_, isIndex := position.(diff.Index)
+		return isIndex
+	}
+	return false
+}
+
 func (f *DeltaFormatter) formatObject(deltas []diff.Delta) (deltaJson map[string]interface{}, err error) {
 	deltaJson = map[string]interface{}{}
 	for _, delta := range deltas {
@@ -71,11 +96,28 @@ func (f *DeltaFormatter) formatObject(deltas []diff.Delta) (deltaJson map[string
 		case *diff.TextDiff:
 			d := delta.(*diff.TextDiff)
 			deltaJson[d.PostPosition().String()] = []interface{}{d.DiffString(), 0, DeltaTextDiff}
+		case *diff.Embedded:
+			d := delta.(*diff.Embedded)
+			deltaJson[d.PostPosition().String()], err = f.formatEmbedded(d)
+			if err != nil {
+				return nil, err
+			}
 		case *diff.Deleted:
 			d := delta.(*diff.Deleted)
 			deltaJson[d.PrePosition().String()] = []interface{}{d.Value, 0, DeltaDelete}
 		case *diff.Moved:
-			return nil, errors.New("Delta type 'Move' is not supported in objects")
+			// renamed keys, an extension to jsondiffpatch
+			d := delta.(*diff.Moved)
+			deltaJson[d.PrePosition().String()] = []interface{}{"", d.PostPosition().String(), DeltaMove}
+			if nested, ok := d.Delta.(diff.Delta); ok {
+				nestedJson, err := f.formatObject([]diff.Delta{nested})
+				if err != nil {
+					return nil, err
+				}
+				for name, value := range nestedJson {
+					deltaJson[name] = value
+				}
+			}
 		default:
 			return nil, errors.New(fmt.Sprintf("Unknown Delta type detected: %#v", delta))
 		}
@@ -110,15 +152,45 @@ func (f *DeltaFormatter) formatArray(deltas []diff.Delta) (deltaJson map[string]
 		case *diff.TextDiff:
 			d := delta.(*diff.TextDiff)
 			deltaJson[d.PostPosition().String()] = []interface{}{d.DiffString(), 0, DeltaTextDiff}
+		case *diff.Embedded:
+			d := delta.(*diff.Embedded)
+			deltaJson[d.PostPosition().String()], err = f.formatEmbedded(d)
+			if err != nil {
+				return nil, err
+			}
 		case *diff.Deleted:
 			d := delta.(*diff.Deleted)
 			deltaJson["_"+d.PrePosition().String()] = []interface{}{d.Value, 0, DeltaDelete}
 		case *diff.Moved:
 			d := delta.(*diff.Moved)
 			deltaJson["_"+d.PrePosition().String()] = []interface{}{"", d.PostPosition(), DeltaMove}
+			if nested, ok := d.Delta.(diff.Delta); ok {
+				nestedJson, err := f.formatArray([]diff.Delta{nested})
+				if err != nil {
+					return nil, err
+				}
+				for name, value := range nestedJson {
+					deltaJson[name] = value
+				}
+			}
 		default:
 			return nil, errors.New(fmt.Sprintf("Unknown Delta type detected: %#v", delta))
 		}
 	}
 	return
 }
+
+// formatEmbedded formats the Deltas of the document as a nested delta,
+// e.g. `[{"name": ["old", "new"]}, 0, 4]`.
+func (f *DeltaFormatter) formatEmbedded(d *diff.Embedded) (deltaJson []interface{}, err error) {
+	var document map[string]interface{}
+	if isArrayDiff(diff.NewDiff(d.Deltas)) {
+		document, err = f.formatArray(d.Deltas)
+	} else {
+		document, err = f.formatObject(d.Deltas)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{document, 0, DeltaEmbedded}, nil
+}
@@ -112,5 +112,115 @@ var _ = Describe("Delta", func() {
 			})
 		})
 
+		Context("There are changes in root arrays", func() {
+			It("Returns an array delta", func() {
+				diff := diff.New().CompareArrays(
+					[]interface{}{"a", "b"},
+					[]interface{}{"a", "c"},
+				)
+
+				f := NewDeltaFormatter()
+				deltaJson, err := f.FormatAsJson(diff)
+				Expect(err).To(BeNil())
+				Expect(deltaJson).To(Equal(
+					map[string]interface{}{
+						"_t": "a",
+						"1":  []interface{}{"b", "c"},
+					},
+				))
+			})
+		})
+
+		Context("There are JSON documents in strings", func() {
+			It("Returns nested deltas which round-trip", func() {
+				a = map[string]interface{}{"config": `{"name": "api", "ports": [80]}`}
+				b = map[string]interface{}{"config": `{"name": "web", "ports": [80, 443]}`}
+
+				d := diff.New(diff.WithEmbeddedJSON()).CompareObjects(a, b)
+
+				f := NewDeltaFormatter()
+				deltaJson, err := f.FormatAsJson(d)
+				Expect(err).To(BeNil())
+				Expect(deltaJson).To(Equal(
+					map[string]interface{}{
+						"config": []interface{}{
+							map[string]interface{}{
+								"name":  []interface{}{"api", "web"},
+								"ports": map[string]interface{}{"_t": "a", "1": []interface{}{float64(443)}},
+							},
+							0,
+							DeltaEmbedded,
+						},
+					},
+				))
+
+				deltaString, err := f.Format(d)
+				Expect(err).To(BeNil())
+				unmarshaled, err := diff.NewUnmarshaller().UnmarshalString(deltaString)
+				Expect(err).To(BeNil())
+				diff.New().ApplyPatch(a, unmarshaled)
+				Expect(a["config"]).To(Equal(`{"name":"web","ports":[80,443]}`))
+			})
+		})
+
+		Context("There are renamed keys", func() {
+			It("Returns moves in objects which round-trip", func() {
+				a = map[string]interface{}{"userName": "alice", "address": map[string]interface{}{"city": "Tokyo", "zip": "100"}}
+				b = map[string]interface{}{"user_name": "alice", "location": map[string]interface{}{"city": "Tokyo", "zip": "101"}}
+
+				d := diff.New(diff.WithRenameDetection(0.5)).CompareObjects(a, b)
+
+				f := NewDeltaFormatter()
+				deltaJson, err := f.FormatAsJson(d)
+				Expect(err).To(BeNil())
+				Expect(deltaJson).To(Equal(
+					map[string]interface{}{
+						"userName": []interface{}{"", "user_name", DeltaMove},
+						"address":  []interface{}{"", "location", DeltaMove},
+						"location": map[string]interface{}{"zip": []interface{}{"100", "101"}},
+					},
+				))
+
+				deltaString, err := f.Format(d)
+				Expect(err).To(BeNil())
+				unmarshaled, err := diff.NewUnmarshaller().UnmarshalString(deltaString)
+				Expect(err).To(BeNil())
+				diff.New().ApplyPatch(a, unmarshaled)
+				Expect(a).To(Equal(b))
+			})
+		})
+
+		Context("There are moved and modified items", func() {
+			It("Returns moves with nested deltas which round-trip", func() {
+				item := func(name string) map[string]interface{} {
+					return map[string]interface{}{"name": name, "kind": "item", "enabled": true}
+				}
+				a = map[string]interface{}{"items": []interface{}{item("a"), item("b"), item("c")}}
+				b = map[string]interface{}{"items": []interface{}{item("C"), item("a"), item("b")}}
+
+				d := diff.New(diff.WithMoveSimilarityThreshold(0.5)).CompareObjects(a, b)
+
+				f := NewDeltaFormatter()
+				deltaJson, err := f.FormatAsJson(d)
+				Expect(err).To(BeNil())
+				Expect(deltaJson).To(Equal(
+					map[string]interface{}{
+						"items": map[string]interface{}{
+							"_t": "a",
+							"_2": []interface{}{"", diff.Index(0), DeltaMove},
+							"0":  map[string]interface{}{"name": []interface{}{"c", "C"}},
+						},
+					},
+				))
+
+				deltaString, err := f.Format(d)
+				Expect(err).To(BeNil())
+				unmarshaled, err := diff.NewUnmarshaller().UnmarshalString(deltaString)
+				Expect(err).To(BeNil())
+				diff.New().ApplyPatch(a, unmarshaled)
+				Expect(a).To(Equal(b))
+			})
+		})
+
 	})
 })
@@ -0,0 +1,229 @@
+package formatter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+// NewDescriptionFormatter returns a formatter that describes changes in
+// plain English sentences, one sentence per line.
+func NewDescriptionFormatter(config DescriptionFormatterConfig) *DescriptionFormatter {
+	return &DescriptionFormatter{
+		config: config,
+	}
+}
+
+type DescriptionFormatter struct {
+	config DescriptionFormatterConfig
+}
+
+type DescriptionFormatterConfig struct {
+	// GroupThreshold is the number of consecutive changes of the same kind
+	// in the same object or array that are described in a single sentence.
+	// Zero disables grouping.
+	GroupThreshold int
+	// MaxValueLength is the maximum length of values quoted in sentences.
+	// Longer values are summarized (e.g. "an object with 3 keys").
+	MaxValueLength int
+}
+
+var DescriptionFormatterDefaultConfig = DescriptionFormatterConfig{
+	GroupThreshold: 3,
+	MaxValueLength: 40,
+}
+
+func (f *DescriptionFormatter) Format(diff diff.Diff) (result string, err error) {
+	buffer := bytes.NewBuffer([]byte{})
+	err = f.FormatTo(buffer, diff)
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (f *DescriptionFormatter) FormatTo(w io.Writer, diff diff.Diff) error {
+	writer := bufio.NewWriter(w)
+	for _, sentence := range f.Describe(diff) {
+		writer.WriteString(sentence)
+		writer.WriteRune('\n')
+	}
+	return writer.Flush()
+}
+
+// Describe returns sentences that describe the Diff.
+func (f *DescriptionFormatter) Describe(diff diff.Diff) (sentences []string) {
+	changes := CollectChanges(diff)
+	sentences = make([]string, 0, len(changes))
+	for i := 0; i < len(changes); {
+		n := 1
+		for i+n < len(changes) && sameGroup(changes[i], changes[i+n]) {
+			n++
+		}
+		if f.config.GroupThreshold > 0 && n >= f.config.GroupThreshold {
+			sentences = append(sentences, f.describeGroup(changes[i:i+n]))
+			i += n
+			continue
+		}
+		sentences = append(sentences, f.DescribeChange(changes[i]))
+		i++
+	}
+	return sentences
+}
+
+// StreamTo returns a DeltaHandler for Differ.CompareStreams that writes
+// a sentence for each change to w. Changes are not grouped.
+func (f *DescriptionFormatter) StreamTo(w io.Writer) diff.DeltaHandler {
+	return func(path []diff.Position, delta diff.Delta) error {
+		for _, change := range DeltaChanges(path, delta) {
+			if _, err := fmt.Fprintln(w, f.DescribeChange(change)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// DescribeChange returns a sentence that describes the Change.
+func (f *DescriptionFormatter) DescribeChange(change Change) string {
+	parent, position := splitPath(change.Path)
+	switch change.Kind {
+	case ChangeAdded:
+		value := f.describeValue(change.NewValue)
+		if f.isQuotable(change.NewValue) {
+			value = "value " + value
+		}
+		return fmt.Sprintf("%s was added%s with %s",
+			describePosition(position), describeIn(parent, "to"), value)
+	case ChangeDeleted:
+		return fmt.Sprintf("%s was removed%s",
+			describePosition(position), describeIn(parent, "from"))
+	case ChangeModified:
+		return fmt.Sprintf("%s changed from %s to %s",
+			describePath(change.Path), f.describeValue(change.OldValue), f.describeValue(change.NewValue))
+	case ChangeTextDiff:
+		return fmt.Sprintf("the text of %s was edited", describePath(change.Path))
+	case ChangeMoved:
+		if _, renamed := change.MovedTo.(diff.Name); renamed {
+			return fmt.Sprintf("%s%s was renamed to `%s`",
+				describePosition(position), describeIn(parent, "in"), change.MovedTo.String())
+		}
+		return fmt.Sprintf("%s%s was moved to position %s",
+			describePosition(position), describeIn(parent, "of"), change.MovedTo.String())
+	}
+	return fmt.Sprintf("%s was changed", describePath(change.Path))
+}
+
+func (f *DescriptionFormatter) describeGroup(changes []Change) string {
+	parent, position := splitPath(changes[0].Path)
+	noun := "keys"
+	if _, ok := position.(diff.Index); ok {
+		noun = "items"
+	}
+
+	names := make([]string, 0, len(changes))
+	for _, change := range changes {
+		_, position := splitPath(change.Path)
+		name := "`" + position.String() + "`"
+		if to, renamed := change.MovedTo.(diff.Name); renamed {
+			name += " to `" + to.String() + "`"
+		}
+		names = append(names, name)
+	}
+	list := strings.Join(names, ", ")
+
+	switch changes[0].Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("%d %s were added%s: %s", len(changes), noun, describeIn(parent, "to"), list)
+	case ChangeDeleted:
+		return fmt.Sprintf("%d %s were removed%s: %s", len(changes), noun, describeIn(parent, "from"), list)
+	case ChangeMoved:
+		if _, renamed := changes[0].MovedTo.(diff.Name); renamed {
+			return fmt.Sprintf("%d %s were renamed%s: %s", len(changes), noun, describeIn(parent, "in"), list)
+		}
+		return fmt.Sprintf("%d %s were reordered%s: %s", len(changes), noun, describeIn(parent, "in"), list)
+	}
+	return fmt.Sprintf("%d values changed%s: %s", len(changes), describeIn(parent, "in"), list)
+}
+
+func (f *DescriptionFormatter) describeValue(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		if text, ok := f.shortJson(value); ok {
+			return text
+		}
+		return fmt.Sprintf("an object with %d keys", len(value.(map[string]interface{})))
+	case *diff.OrderedMap:
+		if text, ok := f.shortJson(value); ok {
+			return text
+		}
+		return fmt.Sprintf("an object with %d keys", value.(*diff.OrderedMap).Len())
+	case []interface{}:
+		if text, ok := f.shortJson(value); ok {
+			return text
+		}
+		return fmt.Sprintf("a list of %d items", len(value.([]interface{})))
+	case string:
+		if text, ok := f.shortJson(value); ok {
+			return text
+		}
+		return fmt.Sprintf("a text of %d characters", len([]rune(value.(string))))
+	}
+	text, _ := f.shortJson(value)
+	return text
+}
+
+func (f *DescriptionFormatter) isQuotable(value interface{}) bool {
+	_, ok := f.shortJson(value)
+	return ok
+}
+
+func (f *DescriptionFormatter) shortJson(value interface{}) (string, bool) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value), true
+	}
+	text := string(bytes)
+	return text, f.config.MaxValueLength <= 0 || len(text) <= f.config.MaxValueLength
+}
+
+func sameGroup(a, b Change) bool {
+	if a.Kind != b.Kind || a.Kind == ChangeTextDiff || len(a.Path) != len(b.Path) {
+		return false
+	}
+	aParent, aPosition := splitPath(a.Path)
+	bParent, bPosition := splitPath(b.Path)
+	_, aIndex := aPosition.(diff.Index)
+	_, bIndex := bPosition.(diff.Index)
+	return aIndex == bIndex && PathString(aParent) == PathString(bParent)
+}
+
+func splitPath(path []diff.Position) (parent []diff.Position, position diff.Position) {
+	if len(path) == 0 {
+		return path, diff.Name("")
+	}
+	return path[:len(path)-1], path[len(path)-1]
+}
+
+func describePath(path []diff.Position) string {
+	return "`" + PathString(path) + "`"
+}
+
+func describePosition(position diff.Position) string {
+	if _, ok := position.(diff.Index); ok {
+		return "item " + position.String()
+	}
+	return "key `" + position.String() + "`"
+}
+
+func describeIn(parent []diff.Position, preposition string) string {
+	if len(parent) == 0 {
+		return ""
+	}
+	return " " + preposition + " " + describePath(parent)
+}
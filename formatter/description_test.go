@@ -0,0 +1,107 @@
+package formatter_test
+
+import (
+	. "github.com/yudai/gojsondiff/formatter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/yudai/gojsondiff/tests"
+
+	"bytes"
+	"strings"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+var _ = Describe("Description", func() {
+	Describe("DescriptionFormatter", func() {
+		var (
+			a, b map[string]interface{}
+		)
+
+		It("Describes the given diff", func() {
+			a = LoadFixture("../FIXTURES/base.json")
+			b = LoadFixture("../FIXTURES/base_changed.json")
+
+			diff := diff.New().CompareObjects(a, b)
+
+			f := NewDescriptionFormatter(DescriptionFormatterDefaultConfig)
+			result, err := f.Format(diff)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				"`arr[2].str` changed from \"pek3f\" to \"changed\"\n" +
+					"`arr[3][1]` changed from \"1\" to \"changed\"\n" +
+					"key `null` was removed\n" +
+					"`obj.arr[2].str` changed from \"eafeb\" to \"changed\"\n" +
+					"key `num` was removed from `obj`\n" +
+					"`obj.obj.num` changed from 14 to 9999\n" +
+					"`obj.obj.str` changed from \"efj3\" to \"changed\"\n" +
+					"key `new` was added to `obj` with value \"added\"\n",
+			))
+		})
+
+		It("Describes moves and summarizes large values", func() {
+			a = LoadFixture("../FIXTURES/move_from.json")
+			b = LoadFixture("../FIXTURES/move_to.json")
+
+			f := NewDescriptionFormatter(DescriptionFormatterDefaultConfig)
+			Expect(f.Describe(diff.New().CompareObjects(a, b))).To(Equal([]string{
+				"item 3 of `arr` was moved to position 1",
+				"item 5 of `arr` was moved to position 3",
+			}))
+
+			a = LoadFixture("../FIXTURES/add_delete_from.json")
+			b = LoadFixture("../FIXTURES/add_delete_to.json")
+			Expect(f.Describe(diff.New().CompareObjects(a, b))).To(Equal([]string{
+				"key `delete` was removed",
+				"key `add` was added with an object with 2 keys",
+			}))
+		})
+
+		It("Describes renamed keys", func() {
+			a = map[string]interface{}{"spec": map[string]interface{}{"userName": "alice"}}
+			b = map[string]interface{}{"spec": map[string]interface{}{"user_name": "alice"}}
+
+			f := NewDescriptionFormatter(DescriptionFormatterDefaultConfig)
+			Expect(f.Describe(diff.New(diff.WithRenameDetection(1)).CompareObjects(a, b))).To(Equal([]string{
+				"key `userName` in `spec` was renamed to `user_name`",
+			}))
+		})
+
+		It("Groups repetitive changes", func() {
+			a = map[string]interface{}{
+				"tags": []interface{}{"a"},
+				"spec": map[string]interface{}{"x": 1.0, "y": 2.0, "z": 3.0},
+			}
+			b = map[string]interface{}{
+				"tags": []interface{}{"a", "b", "c", "d"},
+				"spec": map[string]interface{}{"x": 2.0, "y": 3.0, "z": 4.0},
+			}
+
+			f := NewDescriptionFormatter(DescriptionFormatterDefaultConfig)
+			Expect(f.Describe(diff.New().CompareObjects(a, b))).To(Equal([]string{
+				"3 values changed in `spec`: `x`, `y`, `z`",
+				"3 items were added to `tags`: `1`, `2`, `3`",
+			}))
+
+			f = NewDescriptionFormatter(DescriptionFormatterConfig{})
+			Expect(f.Describe(diff.New().CompareObjects(a, b))).To(HaveLen(6))
+		})
+
+		It("Describes streamed deltas", func() {
+			buffer := bytes.NewBuffer([]byte{})
+			f := NewDescriptionFormatter(DescriptionFormatterDefaultConfig)
+			err := diff.New().CompareStreams(
+				strings.NewReader(`{"spec": {"replicas": 3}, "debug": true}`),
+				strings.NewReader(`{"spec": {"replicas": 5}}`),
+				diff.StreamDefaultConfig,
+				f.StreamTo(buffer),
+			)
+			Expect(err).To(BeNil())
+			Expect(buffer.String()).To(Equal(
+				"`spec.replicas` changed from 3 to 5\n" +
+					"key `debug` was removed\n",
+			))
+		})
+	})
+})
@@ -0,0 +1,138 @@
+// Package formatter provides formatters that render Diffs in various formats.
+// Formatters are registered by name so that applications can look them up at
+// runtime, and third-party formats can be plugged in with Register.
+package formatter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+// A Formatter renders a Diff as a string.
+type Formatter interface {
+	Format(diff diff.Diff) (result string, err error)
+}
+
+// A WriterFormatter is a Formatter that can write its output to an io.Writer
+// without building the whole result in memory.
+type WriterFormatter interface {
+	Formatter
+	FormatTo(w io.Writer, diff diff.Diff) error
+}
+
+// Write renders the Diff to w. The output is streamed when the formatter
+// implements WriterFormatter.
+func Write(w io.Writer, formatter Formatter, diff diff.Diff) error {
+	if f, ok := formatter.(WriterFormatter); ok {
+		return f.FormatTo(w, diff)
+	}
+	result, err := formatter.Format(diff)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, result)
+	return err
+}
+
+// Config holds settings given to a Factory.
+// Formatters ignore settings they do not support.
+type Config struct {
+	// Left is the left side JSON object or array of the Diff
+	Left           interface{}
+	ShowArrayIndex bool
+	Coloring       bool
+	// WordDiff highlights changes in long texts by words
+	WordDiff bool
+	// Width is the width of the output in characters
+	Width int
+	// Template is the template used by the "template" format
+	Template Template
+}
+
+// A Factory creates a Formatter with the given Config.
+type Factory func(config Config) (Formatter, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{}
+)
+
+// Register makes a format available by the name.
+// Register panics when it is called twice with the same name or the factory
+// is nil.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if factory == nil {
+		panic("formatter: Register factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("formatter: Register called twice for format " + name)
+	}
+	factories[name] = factory
+}
+
+// Lookup returns the Factory registered with the name.
+func Lookup(name string) (factory Factory, ok bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	factory, ok = factories[name]
+	return
+}
+
+// Names returns a sorted list of the names of the registered formats.
+func Names() (names []string) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	names = make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// New returns a Formatter of the format registered with the name.
+func New(name string, config Config) (Formatter, error) {
+	factory, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", name)
+	}
+	return factory(config)
+}
+
+func init() {
+	Register("ascii", func(config Config) (Formatter, error) {
+		return NewAsciiFormatter(config.Left, AsciiFormatterConfig{
+			ShowArrayIndex: config.ShowArrayIndex,
+			Coloring:       config.Coloring,
+			WordDiff:       config.WordDiff,
+		}), nil
+	})
+	Register("delta", func(config Config) (Formatter, error) {
+		return NewDeltaFormatter(), nil
+	})
+	Register("side-by-side", func(config Config) (Formatter, error) {
+		sideBySideConfig := SideBySideFormatterDefaultConfig
+		sideBySideConfig.ShowArrayIndex = config.ShowArrayIndex
+		sideBySideConfig.Coloring = config.Coloring
+		if config.Width > 0 {
+			sideBySideConfig.Width = config.Width
+		}
+		return NewSideBySideFormatter(config.Left, sideBySideConfig), nil
+	})
+	Register("description", func(config Config) (Formatter, error) {
+		return NewDescriptionFormatter(DescriptionFormatterDefaultConfig), nil
+	})
+	Register("template", func(config Config) (Formatter, error) {
+		if config.Template == nil {
+			return nil, errors.New("the template format requires a template")
+		}
+		return NewTemplateFormatter(config.Template), nil
+	})
+}
@@ -0,0 +1,88 @@
+package formatter_test
+
+import (
+	. "github.com/yudai/gojsondiff/formatter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/yudai/gojsondiff/tests"
+
+	"bytes"
+	"strconv"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+type countFormatter struct{}
+
+func (f *countFormatter) Format(d diff.Diff) (string, error) {
+	return strconv.Itoa(len(CollectChanges(d))), nil
+}
+
+var _ = Describe("Formatter", func() {
+	var (
+		a, b map[string]interface{}
+		d    diff.Diff
+	)
+
+	BeforeEach(func() {
+		a = LoadFixture("../FIXTURES/base.json")
+		b = LoadFixture("../FIXTURES/base_changed.json")
+		d = diff.New().CompareObjects(a, b)
+	})
+
+	Describe("Registry", func() {
+		It("Provides the built-in formats", func() {
+			Expect(Names()).To(ContainElement("ascii"))
+			Expect(Names()).To(ContainElement("delta"))
+			Expect(Names()).To(ContainElement("side-by-side"))
+			Expect(Names()).To(ContainElement("description"))
+			Expect(Names()).To(ContainElement("template"))
+
+			for _, name := range []string{"ascii", "delta", "side-by-side", "description"} {
+				f, err := New(name, Config{Left: a})
+				Expect(err).To(BeNil())
+				_, streaming := f.(WriterFormatter)
+				Expect(streaming).To(BeTrue())
+			}
+
+			_, err := New("template", Config{})
+			Expect(err).NotTo(BeNil())
+			_, err = New("unknown", Config{})
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Accepts third-party formats", func() {
+			Register("count", func(config Config) (Formatter, error) {
+				return &countFormatter{}, nil
+			})
+			Expect(func() {
+				Register("count", func(config Config) (Formatter, error) { return nil, nil })
+			}).To(Panic())
+
+			factory, ok := Lookup("count")
+			Expect(ok).To(BeTrue())
+			f, err := factory(Config{})
+			Expect(err).To(BeNil())
+
+			buffer := bytes.NewBuffer([]byte{})
+			Expect(Write(buffer, f, d)).To(Succeed())
+			Expect(buffer.String()).To(Equal("8"))
+		})
+	})
+
+	Describe("Write", func() {
+		It("Streams the same output as Format", func() {
+			for _, name := range []string{"ascii", "delta", "side-by-side", "description"} {
+				f, err := New(name, Config{Left: a})
+				Expect(err).To(BeNil())
+				expected, err := f.Format(d)
+				Expect(err).To(BeNil())
+
+				buffer := bytes.NewBuffer([]byte{})
+				Expect(Write(buffer, f, d)).To(Succeed())
+				Expect(buffer.String()).To(Equal(expected))
+			}
+		})
+	})
+})
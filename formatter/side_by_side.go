@@ -0,0 +1,193 @@
+package formatter
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+// NewSideBySideFormatter returns a formatter that renders the left and the
+// right documents in two aligned columns.
+func NewSideBySideFormatter(left interface{}, config SideBySideFormatterConfig) *SideBySideFormatter {
+	return &SideBySideFormatter{
+		left:   left,
+		config: config,
+	}
+}
+
+type SideBySideFormatter struct {
+	left    interface{}
+	config  SideBySideFormatterConfig
+	writer  *bufio.Writer
+	deleted []*AsciiLine
+	added   []*AsciiLine
+}
+
+type SideBySideFormatterConfig struct {
+	// Width is the total width of the output including the gutter
+	Width          int
+	ShowArrayIndex bool
+	Coloring       bool
+}
+
+var SideBySideFormatterDefaultConfig = SideBySideFormatterConfig{
+	Width: 160,
+}
+
+const (
+	SideBySideSame    = " "
+	SideBySideChanged = "|"
+	SideBySideDeleted = "<"
+	SideBySideAdded   = ">"
+	SideBySideMoved   = "~"
+)
+
+// sideBySideMinimumColumn is the narrowest column the formatter renders,
+// used when the configured width is too small to be useful.
+const sideBySideMinimumColumn = 10
+
+type sideBySideRow struct {
+	marker string
+	left   *AsciiLine
+	right  *AsciiLine
+}
+
+func (f *SideBySideFormatter) Format(diff diff.Diff) (result string, err error) {
+	buffer := bytes.NewBuffer([]byte{})
+	err = f.FormatTo(buffer, diff)
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (f *SideBySideFormatter) FormatTo(w io.Writer, diff diff.Diff) error {
+	f.writer = bufio.NewWriter(w)
+	f.deleted = []*AsciiLine{}
+	f.added = []*AsciiLine{}
+
+	ascii := NewAsciiFormatter(f.left, AsciiFormatterConfig{
+		ShowArrayIndex: f.config.ShowArrayIndex,
+	})
+	ascii.emit = f.pushLine
+	if err := ascii.FormatTo(ioutil.Discard, diff); err != nil {
+		return err
+	}
+	f.flushBlock()
+
+	return f.writer.Flush()
+}
+
+// pushLine aligns the lines generated by AsciiFormatter into rows.
+// Unchanged lines are shown on both sides and a block of deleted lines
+// followed by a block of added lines are shown next to each other.
+func (f *SideBySideFormatter) pushLine(line *AsciiLine) {
+	switch line.marker {
+	case AsciiDeleted:
+		if len(f.added) > 0 {
+			f.flushBlock()
+		}
+		f.deleted = append(f.deleted, line)
+	case AsciiAdded:
+		f.added = append(f.added, line)
+	case AsciiMoved:
+		f.flushBlock()
+		f.printRow(sideBySideRow{marker: SideBySideMoved, left: line, right: line})
+	default:
+		f.flushBlock()
+		f.printRow(sideBySideRow{marker: SideBySideSame, left: line, right: line})
+	}
+}
+
+func (f *SideBySideFormatter) flushBlock() {
+	for n := 0; n < len(f.deleted) || n < len(f.added); n++ {
+		switch {
+		case n < len(f.deleted) && n < len(f.added):
+			f.printRow(sideBySideRow{marker: SideBySideChanged, left: f.deleted[n], right: f.added[n]})
+		case n < len(f.deleted):
+			f.printRow(sideBySideRow{marker: SideBySideDeleted, left: f.deleted[n]})
+		default:
+			f.printRow(sideBySideRow{marker: SideBySideAdded, right: f.added[n]})
+		}
+	}
+	f.deleted = f.deleted[:0]
+	f.added = f.added[:0]
+}
+
+func (f *SideBySideFormatter) columnWidth() int {
+	width := (f.config.Width - 3) / 2
+	if width < sideBySideMinimumColumn {
+		width = sideBySideMinimumColumn
+	}
+	return width
+}
+
+func (f *SideBySideFormatter) printRow(row sideBySideRow) {
+	width := f.columnWidth()
+	left := wrapText(lineText(row.left, 0), width)
+	right := wrapText(lineText(row.right, 1), width)
+
+	for n := 0; n < len(left) || n < len(right); n++ {
+		var l, r string
+		if n < len(left) {
+			l = left[n]
+		}
+		if n < len(right) {
+			r = right[n]
+		}
+
+		f.printColumn(l, row.left)
+		f.writer.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(l)))
+		f.writer.WriteString(" " + row.marker)
+		if r != "" {
+			f.writer.WriteRune(' ')
+			f.printColumn(r, row.right)
+		}
+		f.writer.WriteRune('\n')
+	}
+}
+
+func (f *SideBySideFormatter) printColumn(text string, line *AsciiLine) {
+	style, ok := "", false
+	if line != nil {
+		style, ok = AsciiStyles[line.marker]
+	}
+	if f.config.Coloring && ok {
+		f.writer.WriteString("\x1b[" + style + "m")
+	}
+	f.writer.WriteString(text)
+	if f.config.Coloring && ok {
+		f.writer.WriteString("\x1b[0m")
+	}
+}
+
+// lineText returns the text of the line for the side, 0 for the left and 1
+// for the right. Moved items are shown at their position on each side.
+func lineText(line *AsciiLine, side int) string {
+	if line == nil {
+		return ""
+	}
+	text := line.buffer.String()
+	if line.movedKeys != nil {
+		text = line.movedKeys[side] + strings.TrimPrefix(text, line.key)
+	}
+	return strings.Repeat("  ", line.indent) + text
+}
+
+// wrapText splits text into chunks that fit in width runes.
+func wrapText(text string, width int) (chunks []string) {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return []string{""}
+	}
+	for len(runes) > width {
+		chunks = append(chunks, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(chunks, string(runes))
+}
@@ -0,0 +1,111 @@
+package formatter_test
+
+import (
+	. "github.com/yudai/gojsondiff/formatter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/yudai/gojsondiff/tests"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+var _ = Describe("SideBySide", func() {
+	Describe("SideBySideFormatter", func() {
+		var (
+			a, b map[string]interface{}
+		)
+
+		It("Prints the given diff in two columns", func() {
+			a = LoadFixture("../FIXTURES/base.json")
+			b = LoadFixture("../FIXTURES/base_changed.json")
+
+			diff := diff.New().CompareObjects(a, b)
+			Expect(diff.Modified()).To(BeTrue())
+
+			f := NewSideBySideFormatter(a, SideBySideFormatterConfig{Width: 60})
+			result, err := f.Format(diff)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				`{                              {
+  "arr": [                       "arr": [
+    "arr0",                        "arr0",
+    21,                            21,
+    {                              {
+      "num": 1,                      "num": 1,
+      "str": "pek3f"         |       "str": "changed"
+    },                             },
+    [                              [
+      0,                             0,
+      "1"                    |       "changed"
+    ]                              ]
+  ],                             ],
+  "bool": true,                  "bool": true,
+  "null": null,              <
+  "num_float": 39.39,            "num_float": 39.39,
+  "num_int": 13,                 "num_int": 13,
+  "obj": {                       "obj": {
+    "arr": [                       "arr": [
+      17,                            17,
+      "str",                         "str",
+      {                              {
+        "str": "eafeb"       |         "str": "changed"
+      }                              }
+    ],                             ],
+    "num": 19,               <
+    "obj": {                       "obj": {
+      "num": 14,             |       "num": 9999,
+      "str": "efj3"          |       "str": "changed"
+    },                             },
+    "str": "bcded"                 "str": "bcded"
+                             >     "new": "added"
+  },                             },
+  "str": "abcde"                 "str": "abcde"
+}                              }
+`,
+			))
+		})
+
+		It("Wraps lines longer than the column", func() {
+			a = map[string]interface{}{"key": "0123456789"}
+			b = map[string]interface{}{"key": "abc"}
+
+			diff := diff.New().CompareObjects(a, b)
+
+			f := NewSideBySideFormatter(a, SideBySideFormatterConfig{Width: 23})
+			result, err := f.Format(diff)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				`{            {
+  "key": " |   "key": "
+0123456789 | abc"
+"          |
+}            }
+`,
+			))
+		})
+
+		It("Prints moved items at their positions on each side", func() {
+			a = map[string]interface{}{"items": []interface{}{"a", "b", "c", "d"}}
+			d := diff.NewDiff([]diff.Delta{diff.NewArray(diff.Name("items"), []diff.Delta{
+				diff.NewMoved(diff.Index(2), diff.Index(0), "c", nil),
+				diff.NewMoved(diff.Index(3), diff.Index(1), "d", diff.NewModified(diff.Index(1), "d", "D")),
+			})})
+
+			f := NewSideBySideFormatter(a, SideBySideFormatterConfig{Width: 40})
+			result, err := f.Format(d)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				`{                    {
+  "items": [           "items": [
+    "a",                 "a",
+    "b",                 "b",
+    2: "c",        ~     0: "c",
+    3: "d"         |     1: "D"
+  ]                    ]
+}                    }
+`,
+			))
+		})
+	})
+})
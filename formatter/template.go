@@ -0,0 +1,70 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"text/template"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+// A Template is a parsed template to render a Diff.
+// Both *text/template.Template and *html/template.Template satisfy this
+// interface.
+type Template interface {
+	Execute(wr io.Writer, data interface{}) error
+}
+
+// TemplateData is the data given to a Template.
+type TemplateData struct {
+	// Modified is true when the Diff has at least one change
+	Modified bool
+	// Changes holds all changes in the Diff
+	Changes []Change
+}
+
+// TemplateFuncs are functions useful for templates given to
+// TemplateFormatter. Use Funcs() to install them before parsing templates.
+// For html/template, convert the map with html/template.FuncMap.
+var TemplateFuncs = template.FuncMap{
+	"json": templateJson,
+	"path": PathString,
+}
+
+func templateJson(value interface{}) (string, error) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// NewTemplateFormatter returns a formatter that renders Diffs with the given
+// Template. The template is executed with a TemplateData.
+func NewTemplateFormatter(template Template) *TemplateFormatter {
+	return &TemplateFormatter{
+		template: template,
+	}
+}
+
+type TemplateFormatter struct {
+	template Template
+}
+
+func (f *TemplateFormatter) Format(diff diff.Diff) (result string, err error) {
+	buffer := bytes.NewBuffer([]byte{})
+	err = f.FormatTo(buffer, diff)
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (f *TemplateFormatter) FormatTo(w io.Writer, diff diff.Diff) error {
+	data := TemplateData{
+		Modified: diff.Modified(),
+		Changes:  CollectChanges(diff),
+	}
+	return f.template.Execute(w, data)
+}
@@ -0,0 +1,78 @@
+package formatter_test
+
+import (
+	. "github.com/yudai/gojsondiff/formatter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/yudai/gojsondiff/tests"
+
+	htmlTemplate "html/template"
+	"text/template"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+var _ = Describe("Template", func() {
+	Describe("TemplateFormatter", func() {
+		var (
+			a, b map[string]interface{}
+		)
+
+		It("Renders changes with a text template", func() {
+			a = LoadFixture("../FIXTURES/base.json")
+			b = LoadFixture("../FIXTURES/base_changed.json")
+
+			diff := diff.New().CompareObjects(a, b)
+
+			t := template.Must(template.New("report").Funcs(TemplateFuncs).Parse(
+				"{{range .Changes}}{{.Kind}} {{.PathString}} {{json .OldValue}} {{json .NewValue}}\n{{end}}",
+			))
+			f := NewTemplateFormatter(t)
+			result, err := f.Format(diff)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(
+				`modified arr[2].str "pek3f" "changed"
+modified arr[3][1] "1" "changed"
+deleted null null null
+modified obj.arr[2].str "eafeb" "changed"
+deleted obj.num 19 null
+modified obj.obj.num 14 9999
+modified obj.obj.str "efj3" "changed"
+added obj.new null "added"
+`,
+			))
+		})
+
+		It("Renders changes with an html template", func() {
+			a = map[string]interface{}{"<b>": "old"}
+			b = map[string]interface{}{"<b>": "new"}
+
+			diff := diff.New().CompareObjects(a, b)
+
+			t := htmlTemplate.Must(htmlTemplate.New("report").Parse(
+				"{{range .Changes}}<li>{{.PathString}}: {{.NewValue}}</li>{{end}}",
+			))
+			f := NewTemplateFormatter(t)
+			result, err := f.Format(diff)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(`<li>[&#34;&lt;b&gt;&#34;]: new</li>`))
+		})
+
+		It("Exposes text patches and moves", func() {
+			a = LoadFixture("../FIXTURES/long_text_from.json")
+			b = LoadFixture("../FIXTURES/long_text_to.json")
+			changes := CollectChanges(diff.New().CompareObjects(a, b))
+			Expect(changes).To(HaveLen(1))
+			Expect(changes[0].Kind).To(Equal(ChangeTextDiff))
+			Expect(changes[0].TextPatch).To(HavePrefix("@@ -27,14 +27,15 @@"))
+
+			a = LoadFixture("../FIXTURES/move_from.json")
+			b = LoadFixture("../FIXTURES/move_to.json")
+			changes = CollectChanges(diff.New().CompareObjects(a, b))
+			Expect(changes).NotTo(BeEmpty())
+			Expect(changes[0].Kind).To(Equal(ChangeMoved))
+			Expect(changes[0].MovedTo).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,62 @@
+package formatter
+
+import (
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+	diff "github.com/yudai/gojsondiff"
+)
+
+// textDiffValues returns the texts before and after a TextDiff.
+// value is the text in the left object, which is used when the TextDiff does
+// not hold the old value (e.g. unmarshaled from the delta format).
+func textDiffValues(value interface{}, d *diff.TextDiff) (oldText, newText string, ok bool) {
+	oldText, ok = value.(string)
+	if !ok {
+		oldText, ok = d.OldValue.(string)
+		if !ok {
+			return "", "", false
+		}
+	}
+
+	if newText, ok = d.NewValue.(string); ok {
+		return oldText, newText, true
+	}
+
+	patched, successes := dmp.New().PatchApply(d.Diff, oldText)
+	for _, success := range successes {
+		if !success {
+			return "", "", false
+		}
+	}
+	return oldText, patched, true
+}
+
+// inlineDiff returns the differences between two texts by characters or words.
+func inlineDiff(oldText, newText string, words bool) []dmp.Diff {
+	if words {
+		return diff.DiffTexts(oldText, newText, diff.TextByWords)
+	}
+	return diff.DiffTexts(oldText, newText, diff.TextByCharacters)
+}
+
+// embeddedDocument parses the document before an Embedded and returns it with
+// an Object or Array Delta holding the Deltas of the document. value is the
+// string in the left object.
+func embeddedDocument(value interface{}, d *diff.Embedded) (document interface{}, delta diff.Delta, ok bool) {
+	text, ok := value.(string)
+	if !ok {
+		if text, ok = d.OldValue.(string); !ok {
+			return nil, nil, false
+		}
+	}
+	document, err := diff.UnmarshalOrdered([]byte(text))
+	if err != nil {
+		return nil, nil, false
+	}
+	switch document.(type) {
+	case *diff.OrderedMap:
+		return document, diff.NewObject(d.Position, d.Deltas), true
+	case []interface{}:
+		return document, diff.NewArray(d.Position, d.Deltas), true
+	}
+	return nil, nil, false
+}
@@ -0,0 +1,27 @@
+//go:build go1.18
+// +build go1.18
+
+package gojsondiff
+
+import (
+	"reflect"
+)
+
+// Compare compares two Go values of the same type as their JSON encodings,
+// and returns a Diff object. The options configure the Differ as New does.
+// See Differ.CompareValues.
+func Compare[T any](left, right T, opts ...Option) (Diff, error) {
+	return New(opts...).CompareValues(left, right)
+}
+
+// Patch returns a copy of the value with the Diff applied. The value is not
+// modified. See Differ.ApplyPatchToValue.
+func Patch[T any](value T, d Diff) (T, error) {
+	var patched T
+	reflect.ValueOf(&patched).Elem().Set(copyValue(reflect.ValueOf(&value).Elem()))
+	if err := New().ApplyPatchToValue(&patched, d); err != nil {
+		var zero T
+		return zero, err
+	}
+	return patched, nil
+}
@@ -0,0 +1,73 @@
+//go:build go1.18
+// +build go1.18
+
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+)
+
+type genericConfig struct {
+	Name    string            `json:"name"`
+	Ports   []int             `json:"ports"`
+	Labels  map[string]string `json:"labels"`
+	Backend *genericConfig    `json:"backend,omitempty"`
+}
+
+var _ = Describe("Generics", func() {
+	config := func() genericConfig {
+		return genericConfig{
+			Name:    "api",
+			Ports:   []int{80, 443},
+			Labels:  map[string]string{"env": "dev"},
+			Backend: &genericConfig{Name: "db"},
+		}
+	}
+
+	It("compares and patches typed values", func() {
+		left, right := config(), config()
+		right.Ports = []int{443, 8080}
+		right.Labels["env"] = "prod"
+		right.Backend.Name = "cache"
+
+		d, err := Compare(left, right)
+		Expect(err).To(BeNil())
+		Expect(d.Modified()).To(BeTrue())
+
+		patched, err := Patch(left, d)
+		Expect(err).To(BeNil())
+		Expect(patched).To(Equal(right))
+		Expect(left).To(Equal(config()))
+	})
+
+	It("patches pointers and values in the data model of encoding/json", func() {
+		left := map[string]interface{}{"a": []interface{}{1.0}}
+		d, err := Compare(left, map[string]interface{}{"a": []interface{}{1.0, 2.0}})
+		Expect(err).To(BeNil())
+
+		patched, err := Patch(left, d)
+		Expect(err).To(BeNil())
+		Expect(patched).To(Equal(map[string]interface{}{"a": []interface{}{1.0, 2.0}}))
+		Expect(left).To(Equal(map[string]interface{}{"a": []interface{}{1.0}}))
+
+		pointer := &genericConfig{Name: "api"}
+		d, err = Compare(pointer, &genericConfig{Name: "web"})
+		Expect(err).To(BeNil())
+		patchedPointer, err := Patch(pointer, d)
+		Expect(err).To(BeNil())
+		Expect(patchedPointer.Name).To(Equal("web"))
+		Expect(pointer.Name).To(Equal("api"))
+	})
+
+	It("returns patch errors", func() {
+		d, err := Compare(map[string]int{"a": 1}, map[string]int{"a": 1, "b": 2})
+		Expect(err).To(BeNil())
+		_, err = Patch(genericConfig{}, d)
+		Expect(errors.Is(err, ErrFieldNotFound)).To(BeTrue())
+	})
+})
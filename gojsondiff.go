@@ -5,11 +5,13 @@ package gojsondiff
 
 import (
 	"container/list"
+	"context"
 	"encoding/json"
+	"errors"
 	"reflect"
 	"sort"
+	"time"
 
-	dmp "github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/yudai/golcs"
 )
 
@@ -25,6 +27,15 @@ type diff struct {
 	deltas []Delta
 }
 
+// NewDiff returns a Diff holding the deltas, such as ones built by code
+// generated with jdgen.
+func NewDiff(deltas []Delta) Diff {
+	if deltas == nil {
+		deltas = []Delta{}
+	}
+	return &diff{deltas: deltas}
+}
+
 func (diff *diff) Deltas() []Delta {
 	return diff.deltas
 }
@@ -35,14 +46,45 @@ func (diff *diff) Modified() bool {
 
 // A Differ conmapres JSON objects and apply patches
 type Differ struct {
-	textDiffMinimumLength int
+	// Lenient makes Compare accept JSON5 and JSONC documents,
+	// which can contain comments and trailing commas
+	Lenient bool
+	// PreserveKeyOrder makes Compare decode objects into *OrderedMap,
+	// so that Deltas are reported in the order of keys in the documents
+	PreserveKeyOrder bool
+	// RecordPositions makes Compare record the positions of values in the
+	// documents. The returned Diff implements SourceLocator.
+	RecordPositions bool
+
+	textDiffMinimumLength  int
+	ignoreMoves            bool
+	similarityThreshold    float64
+	maxDepth               int
+	arrayStrategy          ArrayStrategy
+	hooks                  []CompareHook
+	embeddedJSON           [][]string
+	textMode               TextMode
+	textModes              []textModeRule
+	renameThreshold        float64
+	moveThreshold          float64
+	hashAlignmentThreshold int
+	maxNodes               int
+	maxSimilarityCells     int
+	timeBudget             time.Duration
+	concurrency            int
+	budget                 *budget
 }
 
-// New returns new Differ with default configuration
-func New() *Differ {
-	return &Differ{
-		textDiffMinimumLength: 30,
+// New returns new Differ with default configuration modified by the options
+func New(opts ...Option) *Differ {
+	differ := &Differ{
+		textDiffMinimumLength:  30,
+		hashAlignmentThreshold: defaultHashAlignmentThreshold,
 	}
+	for _, opt := range opts {
+		opt(differ)
+	}
+	return differ
 }
 
 // Compare compares two JSON strings as []bytes and return a Diff object.
@@ -50,6 +92,32 @@ func (differ *Differ) Compare(
 	left []byte,
 	right []byte,
 ) (Diff, error) {
+	return differ.CompareContext(context.Background(), left, right)
+}
+
+// CompareContext is Compare honouring the cancellation and the deadline of
+// the context, which stop the comparison with the error of the context.
+func (differ *Differ) CompareContext(
+	ctx context.Context,
+	left []byte,
+	right []byte,
+) (Diff, error) {
+	differ = differ.start(ctx)
+	result, err := differ.compare(ctx, left, right)
+	if err != nil {
+		return nil, err
+	}
+	if err := differ.budget.error(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (differ *Differ) compare(ctx context.Context, left []byte, right []byte) (Diff, error) {
+	if differ.Lenient || differ.PreserveKeyOrder || differ.RecordPositions {
+		return differ.compareParsed(ctx, left, right)
+	}
+
 	var leftMap, rightMap map[string]interface{}
 	err := json.Unmarshal(left, &leftMap)
 	if err != nil {
@@ -60,7 +128,65 @@ func (differ *Differ) Compare(
 	if err != nil {
 		return nil, err
 	}
-	return differ.CompareObjects(leftMap, rightMap), nil
+	return differ.CompareObjectsContext(ctx, leftMap, rightMap)
+}
+
+// Unmarshal parses a JSON document in the same way as Compare.
+func (differ *Differ) Unmarshal(data []byte) (interface{}, error) {
+	if !differ.Lenient && !differ.PreserveKeyOrder {
+		var value interface{}
+		err := json.Unmarshal(data, &value)
+		return value, err
+	}
+	value, _, err := differ.parse(data)
+	return value, err
+}
+
+func (differ *Differ) parse(data []byte) (interface{}, SourceMap, error) {
+	p := &parser{data: data, lenient: differ.Lenient, ordered: differ.PreserveKeyOrder}
+	if differ.RecordPositions {
+		p.sourceMap = SourceMap{}
+	}
+	value, err := p.parse()
+	return value, p.sourceMap, err
+}
+
+func (differ *Differ) compareParsed(ctx context.Context, left []byte, right []byte) (Diff, error) {
+	leftValue, leftSourceMap, err := differ.parse(left)
+	if err != nil {
+		return nil, err
+	}
+	rightValue, rightSourceMap, err := differ.parse(right)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Diff
+	switch l := leftValue.(type) {
+	case map[string]interface{}:
+		if r, ok := rightValue.(map[string]interface{}); ok {
+			result, err = differ.CompareObjectsContext(ctx, l, r)
+		}
+	case *OrderedMap:
+		if r, ok := rightValue.(*OrderedMap); ok {
+			result, err = differ.CompareOrderedObjectsContext(ctx, l, r)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, errors.New("documents must be JSON objects")
+	}
+
+	if differ.RecordPositions {
+		return &locatedDiff{
+			diff:  result.(*diff),
+			left:  leftSourceMap,
+			right: rightSourceMap,
+		}, nil
+	}
+	return result, nil
 }
 
 // CompareObjects compares two JSON object as map[string]interface{}
@@ -69,8 +195,40 @@ func (differ *Differ) CompareObjects(
 	left map[string]interface{},
 	right map[string]interface{},
 ) Diff {
-	deltas := differ.compareMaps(left, right)
-	return &diff{deltas: deltas}
+	result, _ := differ.CompareObjectsContext(context.Background(), left, right)
+	return result
+}
+
+// CompareObjectsContext is CompareObjects honouring the context in the same
+// way as CompareContext.
+func (differ *Differ) CompareObjectsContext(
+	ctx context.Context,
+	left map[string]interface{},
+	right map[string]interface{},
+) (Diff, error) {
+	differ = differ.start(ctx)
+	return differ.finish(differ.compareMaps([]Position{}, left, right, nil))
+}
+
+// CompareOrderedObjects compares two JSON objects as *OrderedMap
+// and return a Diff object.
+func (differ *Differ) CompareOrderedObjects(
+	left *OrderedMap,
+	right *OrderedMap,
+) Diff {
+	result, _ := differ.CompareOrderedObjectsContext(context.Background(), left, right)
+	return result
+}
+
+// CompareOrderedObjectsContext is CompareOrderedObjects honouring the context
+// in the same way as CompareContext.
+func (differ *Differ) CompareOrderedObjectsContext(
+	ctx context.Context,
+	left *OrderedMap,
+	right *OrderedMap,
+) (Diff, error) {
+	differ = differ.start(ctx)
+	return differ.finish(differ.compareOrderedMaps([]Position{}, left, right, nil))
 }
 
 // CompareArrays compares two JSON arrays as []interface{}
@@ -79,32 +237,90 @@ func (differ *Differ) CompareArrays(
 	left []interface{},
 	right []interface{},
 ) Diff {
-	deltas := differ.compareArrays(left, right)
-	return &diff{deltas: deltas}
+	result, _ := differ.CompareArraysContext(context.Background(), left, right)
+	return result
+}
+
+// CompareArraysContext is CompareArrays honouring the context in the same
+// way as CompareContext.
+func (differ *Differ) CompareArraysContext(
+	ctx context.Context,
+	left []interface{},
+	right []interface{},
+) (Diff, error) {
+	differ = differ.start(ctx)
+	return differ.finish(differ.compareArrays([]Position{}, left, right, nil))
+}
+
+// finish returns a Diff holding the deltas, or the error of the context that
+// stopped the comparison.
+func (differ *Differ) finish(deltas []Delta) (Diff, error) {
+	if err := differ.budget.error(); err != nil {
+		return nil, err
+	}
+	return &diff{deltas: deltas}, nil
 }
 
 func (differ *Differ) compareMaps(
+	path []Position,
 	left map[string]interface{},
 	right map[string]interface{},
+	policy *valuePolicy,
 ) (deltas []Delta) {
 	deltas = make([]Delta, 0)
 
-	names := sortedKeys(left) // stabilize delta order
-	for _, name := range names {
-		if rightValue, ok := right[name]; ok {
-			same, delta := differ.compareValues(Name(name), left[name], rightValue)
-			if !same {
-				deltas = append(deltas, delta)
+	leftNames := sortedKeys(left) // stabilize delta order
+	rightNames := sortedKeys(right)
+	renamed := differ.detectRenames(path, leftNames, rightNames, left, right, policy)
+	fieldDeltas := differ.compareFields(path, leftNames, left, right, policy)
+
+	for i, name := range leftNames {
+		if _, ok := right[name]; ok {
+			if fieldDeltas[i] != nil {
+				deltas = append(deltas, fieldDeltas[i])
 			}
+		} else if moved, ok := renamed.from[name]; ok {
+			deltas = append(deltas, moved)
 		} else {
-			deltas = append(deltas, NewDeleted(Name(name), left[name]))
+			deltas = append(deltas, NewDeleted(Name(name), policy.field(name).hide(left[name])))
 		}
 	}
 
-	names = sortedKeys(right) // stabilize delta order
-	for _, name := range names {
-		if _, ok := left[name]; !ok {
-			deltas = append(deltas, NewAdded(Name(name), right[name]))
+	for _, name := range rightNames {
+		if _, ok := left[name]; !ok && !renamed.to[name] {
+			deltas = append(deltas, NewAdded(Name(name), policy.field(name).hide(right[name])))
+		}
+	}
+
+	return deltas
+}
+
+func (differ *Differ) compareOrderedMaps(
+	path []Position,
+	left *OrderedMap,
+	right *OrderedMap,
+	policy *valuePolicy,
+) (deltas []Delta) {
+	deltas = make([]Delta, 0)
+
+	renamed := differ.detectRenames(path, left.keys, right.keys, left.values, right.values, policy)
+	fieldDeltas := differ.compareFields(path, left.keys, left.values, right.values, policy)
+
+	for i, name := range left.keys {
+		if _, ok := right.values[name]; ok {
+			if fieldDeltas[i] != nil {
+				deltas = append(deltas, fieldDeltas[i])
+			}
+		} else if moved, ok := renamed.from[name]; ok {
+			deltas = append(deltas, moved)
+		} else {
+			deltas = append(deltas, NewDeleted(Name(name), policy.field(name).hide(left.values[name])))
+		}
+	}
+
+	for _, name := range right.keys {
+		if _, ok := left.values[name]; !ok && !renamed.to[name] {
+			deltas = append(deltas, NewAdded(Name(name), policy.field(name).hide(right.values[name])))
 		}
 	}
 
@@ -116,19 +332,49 @@ func (differ *Differ) ApplyPatch(json map[string]interface{}, patch Diff) {
 	applyDeltas(patch.Deltas(), json)
 }
 
+// ApplyPatchToArray applies a Diff to an JSON array and returns the patched
+// array. This method is destructive.
+func (differ *Differ) ApplyPatchToArray(json []interface{}, patch Diff) []interface{} {
+	return applyDeltas(patch.Deltas(), json).([]interface{})
+}
+
+// ApplyPatchToOrderedMap applies a Diff to an JSON object as *OrderedMap.
+// Added keys are appended to the end. This method is destructive.
+func (differ *Differ) ApplyPatchToOrderedMap(json *OrderedMap, patch Diff) {
+	applyDeltas(patch.Deltas(), json)
+}
+
 type maybe struct {
 	index    int
 	lcsIndex int
 	item     interface{}
+	plain    interface{}
 }
 
 func (differ *Differ) compareArrays(
+	path []Position,
 	left []interface{},
 	right []interface{},
+	policy *valuePolicy,
 ) (deltas []Delta) {
+	switch {
+	case policy.unordered():
+		return differ.compareSets(path, left, right, policy.element())
+	case policy.keyed():
+		return differ.compareKeyedArrays(path, left, right, policy)
+	case differ.arrayStrategy == ArrayUnordered:
+		return differ.compareSets(path, left, right, policy.element())
+	case differ.arrayStrategy == ArrayByIndex:
+		return differ.compareArraysByIndex(path, left, right, policy.element())
+	}
+
 	deltas = make([]Delta, 0)
-	// LCS index pairs
-	lcsPairs := lcs.New(left, right).IndexPairs()
+	elem := policy.element()
+	// compare items regardless of the order of keys
+	plainLeft, plainRight := plainValues(left), plainValues(right)
+	// LCS index pairs, with ids of items when they are hashed
+	lcsPairs, leftIDs, rightIDs := differ.alignArrays(plainLeft, plainRight)
+	hashed := leftIDs != nil
 
 	// list up items not in LCS, they are maybe deleted
 	maybeDeleted := list.New() // but maybe moved or modified
@@ -137,7 +383,7 @@ func (differ *Differ) compareArrays(
 		if lcsI < len(lcsPairs) && lcsPairs[lcsI].Left == i {
 			lcsI++
 		} else {
-			maybeDeleted.PushBack(maybe{index: i, lcsIndex: lcsI, item: leftValue})
+			maybeDeleted.PushBack(maybe{index: i, lcsIndex: lcsI, item: leftValue, plain: plainLeft[i]})
 		}
 	}
 
@@ -148,20 +394,23 @@ func (differ *Differ) compareArrays(
 		if lcsI < len(lcsPairs) && lcsPairs[lcsI].Right == i {
 			lcsI++
 		} else {
-			maybeAdded.PushBack(maybe{index: i, lcsIndex: lcsI, item: rightValue})
+			maybeAdded.PushBack(maybe{index: i, lcsIndex: lcsI, item: rightValue, plain: plainRight[i]})
 		}
 	}
 
 	// find moved items
+	if hashed && !differ.ignoreMoves {
+		deltas = append(deltas, findMovesByID(maybeDeleted, maybeAdded, leftIDs, rightIDs, elem)...)
+	}
 	var delNext *list.Element // for prefetch to remove item in iteration
-	for delCandidate := maybeDeleted.Front(); delCandidate != nil; delCandidate = delNext {
+	for delCandidate := maybeDeleted.Front(); delCandidate != nil && !differ.ignoreMoves && !hashed; delCandidate = delNext {
 		delCan := delCandidate.Value.(maybe)
 		delNext = delCandidate.Next()
 
 		for addCandidate := maybeAdded.Front(); addCandidate != nil; addCandidate = addCandidate.Next() {
 			addCan := addCandidate.Value.(maybe)
-			if reflect.DeepEqual(delCan.item, addCan.item) {
-				deltas = append(deltas, NewMoved(Index(delCan.index), Index(addCan.index), delCan.item, nil))
+			if reflect.DeepEqual(delCan.plain, addCan.plain) {
+				deltas = append(deltas, NewMoved(Index(delCan.index), Index(addCan.index), elem.hide(delCan.item), nil))
 				maybeAdded.Remove(addCandidate)
 				maybeDeleted.Remove(delCandidate)
 				break
@@ -169,6 +418,12 @@ func (differ *Differ) compareArrays(
 		}
 	}
 
+	// find moved and modified items
+	if differ.moveThreshold > 0 && !differ.ignoreMoves && !differ.budget.exhausted() {
+		bucketed := hashed || differ.exceedsSimilarityCells(maybeDeleted.Len()*maybeAdded.Len())
+		deltas = append(deltas, differ.findModifiedMoves(path, maybeDeleted, maybeAdded, elem, bucketed)...)
+	}
+
 	// find modified or add+del
 	prevIndexDel := 0
 	prevIndexAdd := 0
@@ -185,11 +440,9 @@ func (differ *Differ) compareArrays(
 			prevIndexAdd = lcsPair.Right
 		}
 
-		var delSlice []maybe
+		delSlice := make([]maybe, 0)
 		if delSize > 0 {
 			delSlice = make([]maybe, 0, delSize)
-		} else {
-			delSlice = make([]maybe, 0, maybeDeleted.Len())
 		}
 		for ; delElement != nil; delElement = delElement.Next() {
 			d := delElement.Value.(maybe)
@@ -199,11 +452,9 @@ func (differ *Differ) compareArrays(
 			delSlice = append(delSlice, d)
 		}
 
-		var addSlice []maybe
+		addSlice := make([]maybe, 0)
 		if addSize > 0 {
 			addSlice = make([]maybe, 0, addSize)
-		} else {
-			addSlice = make([]maybe, 0, maybeAdded.Len())
 		}
 		for ; addElement != nil; addElement = addElement.Next() {
 			a := addElement.Value.(maybe)
@@ -213,19 +464,24 @@ func (differ *Differ) compareArrays(
 			addSlice = append(addSlice, a)
 		}
 
-		if len(delSlice) > 0 && len(addSlice) > 0 {
+		if len(delSlice) > 0 && len(addSlice) > 0 && !differ.budget.exhausted() {
 			var bestDeltas []Delta
-			bestDeltas, delSlice, addSlice = differ.maximizeSimilarities(delSlice, addSlice)
+			cells := len(delSlice) * len(addSlice)
+			if (hashed && cells > maxSimilarityCells) || differ.exceedsSimilarityCells(cells) {
+				bestDeltas, delSlice, addSlice = differ.pairByPosition(path, delSlice, addSlice, elem)
+			} else {
+				bestDeltas, delSlice, addSlice = differ.maximizeSimilarities(path, delSlice, addSlice, elem)
+			}
 			for _, delta := range bestDeltas {
 				deltas = append(deltas, delta)
 			}
 		}
 
 		for _, del := range delSlice {
-			deltas = append(deltas, NewDeleted(Index(del.index), del.item))
+			deltas = append(deltas, NewDeleted(Index(del.index), elem.hide(del.item)))
 		}
 		for _, add := range addSlice {
-			deltas = append(deltas, NewAdded(Index(add.index), add.item))
+			deltas = append(deltas, NewAdded(Index(add.index), elem.hide(add.item)))
 		}
 	}
 
@@ -233,41 +489,84 @@ func (differ *Differ) compareArrays(
 }
 
 func (differ *Differ) compareValues(
+	path []Position,
 	position Position,
 	left interface{},
 	right interface{},
+	policy *valuePolicy,
 ) (same bool, delta Delta) {
+	// hooks decide the equality of redacted values without revealing them
+	if policy.redacted() {
+		return differ.compareRedacted(path, position, left, right, policy)
+	}
+
+	if len(differ.hooks) > 0 {
+		valuePath := childPosition(path, position)
+		for _, hook := range differ.hooks {
+			if delta, handled := hook(valuePath, left, right); handled {
+				return delta == nil, delta
+			}
+		}
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
-		return false, NewModified(position, left, right)
+		return false, NewModified(position, policy.hide(left), policy.hide(right))
+	}
+
+	if !differ.budget.spend() || (differ.maxDepth > 0 && len(path)+1 >= differ.maxDepth) {
+		switch left.(type) {
+		case map[string]interface{}, *OrderedMap, []interface{}:
+			// compared as a whole without descending
+			if reflect.DeepEqual(plainValue(left), plainValue(right)) {
+				return true, nil
+			}
+			return false, NewModified(position, policy.hide(left), policy.hide(right))
+		}
 	}
 
 	switch left.(type) {
 
 	case map[string]interface{}:
 		l := left.(map[string]interface{})
-		childDeltas := differ.compareMaps(l, right.(map[string]interface{}))
+		childDeltas := differ.compareMaps(childPosition(path, position), l, right.(map[string]interface{}), policy)
+		if len(childDeltas) > 0 {
+			return false, NewObject(position, childDeltas)
+		}
+
+	case *OrderedMap:
+		l := left.(*OrderedMap)
+		childDeltas := differ.compareOrderedMaps(childPosition(path, position), l, right.(*OrderedMap), policy)
 		if len(childDeltas) > 0 {
 			return false, NewObject(position, childDeltas)
 		}
 
 	case []interface{}:
 		l := left.([]interface{})
-		childDeltas := differ.compareArrays(l, right.([]interface{}))
+		childDeltas := differ.compareArrays(childPosition(path, position), l, right.([]interface{}), policy)
 
 		if len(childDeltas) > 0 {
 			return false, NewArray(position, childDeltas)
 		}
 
 	default:
-		if !reflect.DeepEqual(left, right) {
+		if !reflect.DeepEqual(left, right) && !policy.tolerates(left, right) {
+
+			if l, isString := left.(string); isString && len(differ.embeddedJSON) > 0 &&
+				differ.embeddedJSONAt(childPosition(path, position)) {
+				if same, delta, ok := differ.compareEmbedded(path, position, l, right.(string)); ok {
+					return same, delta
+				}
+			}
 
 			if reflect.ValueOf(left).Kind() == reflect.String &&
 				reflect.ValueOf(right).Kind() == reflect.String &&
+				differ.textDiffMinimumLength >= 0 &&
 				differ.textDiffMinimumLength <= len(left.(string)) {
 
-				textDiff := dmp.New()
-				patchs := textDiff.PatchMake(left.(string), right.(string))
-				return false, NewTextDiff(position, patchs, left, right)
+				mode := differ.textModeAt(childPosition(path, position), left.(string), right.(string))
+				textDiff := NewTextDiff(position, textPatches(left.(string), right.(string), mode), left, right)
+				textDiff.Mode = mode
+				return false, textDiff
 
 			} else {
 				return false, NewModified(position, left, right)
@@ -279,6 +578,12 @@ func (differ *Differ) compareValues(
 }
 
 func applyDeltas(deltas []Delta, object interface{}) interface{} {
+	var keys []string
+	ordered, isOrdered := object.(*OrderedMap)
+	if isOrdered {
+		keys = append([]string(nil), ordered.keys...)
+	}
+
 	preDeltas := make(preDeltas, 0)
 	for _, delta := range deltas {
 		switch delta.(type) {
@@ -304,19 +609,29 @@ func applyDeltas(deltas []Delta, object interface{}) interface{} {
 		object = delta.PostApply(object)
 	}
 
+	if isOrdered {
+		ordered.placeRenamedKeys(keys, deltas)
+	}
 	return object
 }
 
-func (differ *Differ) maximizeSimilarities(left []maybe, right []maybe) (resultDeltas []Delta, freeLeft, freeRight []maybe) {
+func (differ *Differ) maximizeSimilarities(path []Position, left []maybe, right []maybe, policy *valuePolicy) (resultDeltas []Delta, freeLeft, freeRight []maybe) {
 	deltaTable := make([][]Delta, len(left))
 	for i := 0; i < len(left); i++ {
 		deltaTable[i] = make([]Delta, len(right))
 	}
-	for i, leftValue := range left {
+	differ.parallel(len(left), func(i int) {
 		for j, rightValue := range right {
-			_, delta := differ.compareValues(Index(rightValue.index), leftValue.item, rightValue.item)
+			if differ.budget.exhausted() {
+				return
+			}
+			_, delta := differ.compareValues(path, Index(rightValue.index), left[i].item, rightValue.item, policy)
 			deltaTable[i][j] = delta
 		}
+	})
+	if differ.budget.exhausted() {
+		// the rest of the table would be compared as a whole
+		return []Delta{}, left, right
 	}
 
 	sizeX := len(left) + 1 // margins for both sides
@@ -331,7 +646,14 @@ func (differ *Differ) maximizeSimilarities(left []maybe, right []maybe) (resultD
 		for y := sizeY - 2; y >= 0; y-- {
 			prevX := dpTable[x+1][y]
 			prevY := dpTable[x][y+1]
-			score := deltaTable[x][y].Similarity() + dpTable[x+1][y+1]
+			similarity := 1.0 // same within the policy
+			if deltaTable[x][y] != nil {
+				similarity = deltaTable[x][y].Similarity()
+			}
+			if similarity < differ.similarityThreshold {
+				similarity = 0
+			}
+			score := similarity + dpTable[x+1][y+1]
 
 			dpTable[x][y] = max(prevX, prevY, score)
 		}
@@ -363,16 +685,22 @@ func (differ *Differ) maximizeSimilarities(left []maybe, right []maybe) (resultD
 			freeRight = append(freeRight, right[y])
 			y++
 		} else {
-			resultDeltas = append(resultDeltas, deltaTable[x][y])
+			if delta := deltaTable[x][y]; delta != nil && delta.Similarity() < differ.similarityThreshold {
+				// too different to be a modification
+				freeLeft = append(freeLeft, left[x])
+				freeRight = append(freeRight, right[y])
+			} else if delta != nil {
+				resultDeltas = append(resultDeltas, delta)
+			}
 			x++
 			y++
 		}
 	}
 	for ; x < sizeX-1; x++ {
-		freeLeft = append(freeLeft, left[x-1])
+		freeLeft = append(freeLeft, left[x])
 	}
 	for ; y < sizeY-1; y++ {
-		freeRight = append(freeRight, right[y-1])
+		freeRight = append(freeRight, right[y])
 	}
 
 	return resultDeltas, freeLeft, freeRight
@@ -68,6 +68,25 @@ var _ = Describe("Gojsondiff", func() {
 				})
 			})
 
+			Context("There are items left unpaired in an array", func() {
+				It("Detects changes", func() {
+					a := map[string]interface{}{"arr": []interface{}{
+						map[string]interface{}{"id": 2.0, "name": "c"},
+						map[string]interface{}{"id": 2.0, "name": "c"},
+					}}
+					b := map[string]interface{}{"arr": []interface{}{
+						map[string]interface{}{"id": 3.0, "name": "b"},
+						map[string]interface{}{"id": 3.0, "name": "b"},
+						map[string]interface{}{"id": 2.0, "name": "a"},
+					}}
+
+					diff := differ.CompareObjects(a, b)
+					Expect(diff.Modified()).To(BeTrue())
+					differ.ApplyPatch(a, diff)
+					Expect(a).To(Equal(b))
+				})
+			})
+
 			Context("There are long text diff", func() {
 				It("Detects changes", func() {
 					a = LoadFixture("FIXTURES/long_text_from.json")
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
 
 	"github.com/urfave/cli"
 
@@ -22,12 +26,27 @@ func main() {
 		cli.StringFlag{
 			Name:   "format, f",
 			Value:  "ascii",
-			Usage:  "Diff Output Format (ascii, delta)",
+			Usage:  "Diff Output Format (ascii, delta, side-by-side, description, template)",
 			EnvVar: "DIFF_FORMAT",
 		},
+		cli.StringFlag{
+			Name:   "input, i",
+			Value:  "auto",
+			Usage:  "Input Format (auto, json, json5, yaml, ndjson). auto detects the format by the file extension",
+			EnvVar: "INPUT_FORMAT",
+		},
+		cli.BoolFlag{
+			Name:  "keep-order",
+			Usage: "Keep the order of keys in JSON input instead of sorting them",
+		},
+		cli.BoolFlag{
+			Name:   "lenient, l",
+			Usage:  "Accept comments, trailing commas and unquoted keys in JSON input (same as --input json5)",
+			EnvVar: "LENIENT",
+		},
 		cli.BoolFlag{
 			Name:   "coloring, c",
-			Usage:  "Enable coloring in the ASCII mode (not available in the delta mode)",
+			Usage:  "Enable coloring in the ASCII and side-by-side modes (not available in the delta mode)",
 			EnvVar: "COLORING",
 		},
 		cli.BoolFlag{
@@ -35,11 +54,70 @@ func main() {
 			Usage:  "Suppress output, if no differences are found",
 			EnvVar: "QUIET",
 		},
+		cli.IntFlag{
+			Name:   "width, w",
+			Value:  160,
+			Usage:  "Terminal width for the side-by-side mode",
+			EnvVar: "COLUMNS",
+		},
+		cli.BoolFlag{
+			Name:  "word-diff",
+			Usage: "Highlight changes in long texts by words instead of characters in the ASCII mode",
+		},
+		cli.StringSliceFlag{
+			Name:  "embedded-json, e",
+			Usage: "Compare strings holding JSON documents at paths matching the pattern structurally (e.g. '/**/config', '/**' for all)",
+		},
+		cli.StringFlag{
+			Name:  "text-mode",
+			Usage: "Granularity of diffs of long texts: 'chars', 'words', 'lines' or 'auto' (lines for multi-line texts)",
+			Value: "chars",
+		},
+		cli.Float64Flag{
+			Name:  "renames",
+			Usage: "Detect renamed keys whose values are at least this similar, from 0 to 1 (1 for the same values, 0 disables)",
+		},
+		cli.Float64Flag{
+			Name:  "moves",
+			Usage: "Detect moved array items changed but at least this similar, from 0 to 1 (0 detects only unchanged items)",
+		},
+		cli.DurationFlag{
+			Name:  "time-budget",
+			Usage: "Report coarser changes of the remaining values once the comparison takes this long (e.g. 10s, 0 for no limit)",
+		},
+		cli.IntFlag{
+			Name:  "max-nodes",
+			Usage: "Report coarser changes of the remaining values once this many values are compared (0 for no limit)",
+		},
+		cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "Compare large nested objects and arrays on up to this many goroutines (0 or 1 compares sequentially)",
+		},
+		cli.BoolFlag{
+			Name:  "positions, p",
+			Usage: "Print each change with its file, line and column as 'file:line:column: description'",
+		},
+		cli.BoolFlag{
+			Name:  "stream, s",
+			Usage: "Compare large JSON files without loading them into memory, and describe changes as they are found",
+		},
+		cli.StringFlag{
+			Name:  "key, k",
+			Usage: "Comma separated fields that identify NDJSON records (e.g. id,user.id). Records are matched by line if not given. Records of the second file are loaded into memory unless --sorted is given",
+		},
+		cli.BoolFlag{
+			Name:  "sorted",
+			Usage: "NDJSON files are sorted by the key, compare them without loading records into memory",
+		},
+		cli.StringFlag{
+			Name:  "template, t",
+			Usage: "Render the diff with the given Go text/template file",
+		},
 	}
 
 	app.Action = func(c *cli.Context) error {
 		if len(c.Args()) < 2 {
-			fmt.Println("Not enough arguments.\n")
+			fmt.Print("Not enough arguments.\n\n")
 			fmt.Printf("Usage: %s json_file another_json_file\n", app.Name)
 			os.Exit(1)
 		}
@@ -47,6 +125,13 @@ func main() {
 		aFilePath := c.Args()[0]
 		bFilePath := c.Args()[1]
 
+		if isNDJSON(c.String("input"), aFilePath) {
+			return diffRecords(c, aFilePath, bFilePath)
+		}
+		if c.Bool("stream") {
+			return diffStreams(aFilePath, bFilePath)
+		}
+
 		// Prepare your JSON string as `[]byte`, not `string`
 		aString, err := ioutil.ReadFile(aFilePath)
 		if err != nil {
@@ -62,43 +147,58 @@ func main() {
 		}
 
 		// Then, compare them
-		differ := diff.New()
-		d, err := differ.Compare(aString, bString)
+		textMode, ok := textModes[c.String("text-mode")]
+		if !ok {
+			fmt.Printf("Unknown text mode: %s\n", c.String("text-mode"))
+			os.Exit(1)
+		}
+		differ := diff.New(
+			diff.WithEmbeddedJSONAt(c.StringSlice("embedded-json")...),
+			diff.WithTextMode(textMode),
+			diff.WithRenameDetection(c.Float64("renames")),
+			diff.WithMoveSimilarityThreshold(c.Float64("moves")),
+			diff.WithTimeBudget(c.Duration("time-budget")),
+			diff.WithMaxNodes(c.Int("max-nodes")),
+			diff.WithConcurrency(c.Int("concurrency")),
+		)
+		var d diff.Diff
+		var aJson interface{}
+		if isYAML(c.String("input"), aFilePath) {
+			d, err = differ.CompareYAML(aString, bString)
+			if err == nil {
+				aDocuments, _ := diff.UnmarshalYAML(aString)
+				bDocuments, _ := diff.UnmarshalYAML(bString)
+				aJson, _ = diff.YAMLRoots(aDocuments, bDocuments)
+			}
+		} else {
+			differ.Lenient = isJSON5(c, aFilePath)
+			differ.PreserveKeyOrder = c.Bool("keep-order")
+			differ.RecordPositions = c.Bool("positions")
+			d, err = differ.Compare(aString, bString)
+			if err == nil {
+				aJson, _ = differ.Unmarshal(aString)
+			}
+		}
 		if err != nil {
 			fmt.Printf("Failed to unmarshal file: %s\n", err.Error())
 			os.Exit(3)
 		}
 
 		// Output the result
+		if locator, ok := d.(diff.SourceLocator); ok {
+			printPositions(aFilePath, bFilePath, locator, d)
+			if d.Modified() {
+				return cli.NewExitError("", 1)
+			}
+			return nil
+		}
 		if d.Modified() || !c.Bool("quiet") {
-			format := c.String("format")
-			var diffString string
-			if format == "ascii" {
-				var aJson map[string]interface{}
-				json.Unmarshal(aString, &aJson)
-
-				config := formatter.AsciiFormatterConfig{
-					ShowArrayIndex: true,
-					Coloring:       c.Bool("coloring"),
-				}
-
-				formatter := formatter.NewAsciiFormatter(aJson, config)
-				diffString, err = formatter.Format(d)
-				if err != nil {
-					// No error can occur
-				}
-			} else if format == "delta" {
-				formatter := formatter.NewDeltaFormatter()
-				diffString, err = formatter.Format(d)
-				if err != nil {
-					// No error can occur
-				}
-			} else {
-				fmt.Printf("Unknown Foramt %s\n", format)
+			f := newFormatter(c)(aJson)
+			err = formatter.Write(os.Stdout, f, d)
+			if err != nil {
+				fmt.Printf("Failed to format the diff: %s\n", err.Error())
 				os.Exit(4)
 			}
-
-			fmt.Print(diffString)
 			return cli.NewExitError("", 1)
 		}
 		return nil
@@ -106,3 +206,202 @@ func main() {
 
 	app.Run(os.Args)
 }
+
+// newFormatter returns a function that creates the formatter specified by
+// the flags for the left side value.
+func newFormatter(c *cli.Context) func(left interface{}) formatter.Formatter {
+	format := c.String("format")
+
+	config := formatter.Config{
+		ShowArrayIndex: true,
+		Coloring:       c.Bool("coloring"),
+		WordDiff:       c.Bool("word-diff"),
+		Width:          c.Int("width"),
+	}
+
+	if templatePath := c.String("template"); templatePath != "" {
+		templateString, err := ioutil.ReadFile(templatePath)
+		if err != nil {
+			fmt.Printf("Failed to open file '%s': %s\n", templatePath, err.Error())
+			os.Exit(2)
+		}
+		config.Template, err = template.New(templatePath).Funcs(formatter.TemplateFuncs).Parse(string(templateString))
+		if err != nil {
+			fmt.Printf("Failed to parse template '%s': %s\n", templatePath, err.Error())
+			os.Exit(4)
+		}
+		format = "template"
+	}
+
+	return func(left interface{}) formatter.Formatter {
+		config.Left = left
+		f, err := formatter.New(format, config)
+		if err != nil {
+			fmt.Printf("Unknown Format %s (available: %s)\n", format, strings.Join(formatter.Names(), ", "))
+			os.Exit(4)
+		}
+		return f
+	}
+}
+
+func diffRecords(c *cli.Context, aFilePath string, bFilePath string) error {
+	aFile, err := os.Open(aFilePath)
+	if err != nil {
+		fmt.Printf("Failed to open file '%s': %s\n", aFilePath, err.Error())
+		os.Exit(2)
+	}
+	defer aFile.Close()
+	bFile, err := os.Open(bFilePath)
+	if err != nil {
+		fmt.Printf("Failed to open file '%s': %s\n", bFilePath, err.Error())
+		os.Exit(2)
+	}
+	defer bFile.Close()
+
+	config := diff.RecordsConfig{Sorted: c.Bool("sorted")}
+	if key := c.String("key"); key != "" {
+		config.Key = diff.KeyByFields(strings.Split(key, ",")...)
+	}
+
+	differ := diff.New()
+	differ.Lenient = c.Bool("lenient")
+	newRecordFormatter := newFormatter(c)
+	modified := false
+	err = differ.CompareRecords(aFile, bFile, config, func(record diff.RecordDiff) error {
+		modified = true
+		switch record.Change {
+		case diff.RecordAdded:
+			value, _ := json.Marshal(record.Right)
+			fmt.Printf("+ %s: %s\n", recordLabel(record), value)
+		case diff.RecordDeleted:
+			value, _ := json.Marshal(record.Left)
+			fmt.Printf("- %s: %s\n", recordLabel(record), value)
+		case diff.RecordModified:
+			fmt.Printf("~ %s\n", recordLabel(record))
+			return formatter.Write(os.Stdout, newRecordFormatter(record.Left), record.Diff)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Failed to compare records: %s\n", err.Error())
+		os.Exit(3)
+	}
+
+	if modified {
+		return cli.NewExitError("", 1)
+	}
+	return nil
+}
+
+func diffStreams(aFilePath string, bFilePath string) error {
+	aFile, err := os.Open(aFilePath)
+	if err != nil {
+		fmt.Printf("Failed to open file '%s': %s\n", aFilePath, err.Error())
+		os.Exit(2)
+	}
+	defer aFile.Close()
+	bFile, err := os.Open(bFilePath)
+	if err != nil {
+		fmt.Printf("Failed to open file '%s': %s\n", bFilePath, err.Error())
+		os.Exit(2)
+	}
+	defer bFile.Close()
+
+	writer := bufio.NewWriter(os.Stdout)
+	defer writer.Flush()
+	describe := formatter.NewDescriptionFormatter(formatter.DescriptionFormatterDefaultConfig).StreamTo(writer)
+	modified := false
+	err = diff.New().CompareStreams(aFile, bFile, diff.StreamDefaultConfig, func(path []diff.Position, delta diff.Delta) error {
+		modified = true
+		return describe(path, delta)
+	})
+	if err != nil {
+		writer.Flush()
+		fmt.Printf("Failed to compare files: %s\n", err.Error())
+		os.Exit(3)
+	}
+
+	if modified {
+		writer.Flush()
+		return cli.NewExitError("", 1)
+	}
+	return nil
+}
+
+// printPositions prints changes in the format of compiler errors, which
+// editors and CI systems can link to the source. Deleted values are located
+// in the left file, and others in the right file.
+func printPositions(aFilePath string, bFilePath string, locator diff.SourceLocator, d diff.Diff) {
+	describer := formatter.NewDescriptionFormatter(formatter.DescriptionFormatterDefaultConfig)
+	for _, change := range formatter.CollectChanges(d) {
+		filePath, sourceMap, path := bFilePath, locator.RightSourceMap(), change.Path
+		switch change.Kind {
+		case formatter.ChangeDeleted:
+			filePath, sourceMap = aFilePath, locator.LeftSourceMap()
+		case formatter.ChangeMoved:
+			path = append(path[:len(path)-1:len(path)-1], change.MovedTo)
+		}
+		position, _ := sourceMap.Lookup(path)
+		fmt.Printf("%s:%d:%d: %s\n", filePath, position.Line, position.Column, describer.DescribeChange(change))
+	}
+}
+
+func recordLabel(record diff.RecordDiff) string {
+	var lines string
+	switch {
+	case record.LeftLine == 0:
+		lines = fmt.Sprintf("line %d", record.RightLine)
+	case record.RightLine == 0:
+		lines = fmt.Sprintf("line %d", record.LeftLine)
+	case record.LeftLine == record.RightLine:
+		lines = fmt.Sprintf("line %d", record.LeftLine)
+	default:
+		lines = fmt.Sprintf("lines %d, %d", record.LeftLine, record.RightLine)
+	}
+	if record.Key == nil {
+		return lines
+	}
+	return fmt.Sprintf("record %s (%s)", record.KeyString(), lines)
+}
+
+func isNDJSON(format string, path string) bool {
+	switch format {
+	case "ndjson", "jsonl":
+		return true
+	case "auto":
+		extension := strings.ToLower(filepath.Ext(path))
+		return extension == ".ndjson" || extension == ".jsonl"
+	}
+	return false
+}
+
+func isYAML(format string, path string) bool {
+	switch format {
+	case "yaml":
+		return true
+	case "auto":
+		extension := strings.ToLower(filepath.Ext(path))
+		return extension == ".yaml" || extension == ".yml"
+	}
+	return false
+}
+
+func isJSON5(c *cli.Context, path string) bool {
+	switch c.String("input") {
+	case "json5", "jsonc":
+		return true
+	case "auto":
+		extension := strings.ToLower(filepath.Ext(path))
+		if extension == ".json5" || extension == ".jsonc" {
+			return true
+		}
+	}
+	return c.Bool("lenient")
+}
+
+var textModes = map[string]diff.TextMode{
+	"chars": diff.TextByCharacters,
+	"words": diff.TextByWords,
+	"lines": diff.TextByLines,
+	"auto":  diff.TextAuto,
+}
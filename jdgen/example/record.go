@@ -0,0 +1,36 @@
+// Package example holds types with differs generated by jdgen.
+package example
+
+import (
+	"encoding/json"
+	"time"
+)
+
+//go:generate go run github.com/yudai/gojsondiff/jdgen -type Record,Item
+
+type Status string
+
+type Record struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	Status    Status            `json:"status,omitempty"`
+	Score     float32           `json:"score"`
+	Active    bool              `json:"active"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Window    [2]int            `json:"window"`
+	Item      Item              `json:"item"`
+	Parent    *Item             `json:"parent,omitempty"`
+	Items     []Item            `json:"items"`
+	Extra     interface{}       `json:"extra"`
+	Raw       json.RawMessage   `json:"raw,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Internal  string            `json:"-"`
+	Cache     string            `json:"cache" diff:"-"`
+	private   int
+}
+
+type Item struct {
+	Key   string `json:"key"`
+	Count int    `json:"count,omitempty"`
+}
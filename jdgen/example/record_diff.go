@@ -0,0 +1,278 @@
+// Code generated by jdgen; DO NOT EDIT.
+
+package example
+
+import diff "github.com/yudai/gojsondiff"
+
+var recordDiffer = diff.New()
+
+// DiffRecord compares two Record values as their JSON encodings and returns a Diff.
+// The values must not be nil.
+func DiffRecord(left, right *Record) (diff.Diff, error) {
+	deltas, err := recordDeltas(left, right)
+	if err != nil {
+		return nil, err
+	}
+	return diff.NewDiff(deltas), nil
+}
+
+func recordDeltas(left, right *Record) ([]diff.Delta, error) {
+	var deltas, added []diff.Delta
+	var changed bool
+
+	// Active
+	if left.Active != right.Active {
+		deltas = append(deltas, diff.NewModified(diff.Name("active"), left.Active, right.Active))
+	}
+
+	// CreatedAt
+	if !left.CreatedAt.Equal(right.CreatedAt) {
+		delta, err := recordDiffer.CompareAt(diff.Name("createdAt"), left.CreatedAt, right.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if delta != nil {
+			deltas = append(deltas, delta)
+		}
+	}
+
+	// Extra
+	{
+		delta, err := recordDiffer.CompareAt(diff.Name("extra"), left.Extra, right.Extra)
+		if err != nil {
+			return nil, err
+		}
+		if delta != nil {
+			deltas = append(deltas, delta)
+		}
+	}
+
+	// ID
+	if left.ID != right.ID {
+		deltas = append(deltas, diff.NewModified(diff.Name("id"), float64(left.ID), float64(right.ID)))
+	}
+
+	// Item
+	{
+		childDeltas, err := itemDeltas(&left.Item, &right.Item)
+		if err != nil {
+			return nil, err
+		}
+		if len(childDeltas) > 0 {
+			deltas = append(deltas, diff.NewObject(diff.Name("item"), childDeltas))
+		}
+	}
+
+	// Items
+	{
+		delta, err := recordDiffer.CompareAt(diff.Name("items"), left.Items, right.Items)
+		if err != nil {
+			return nil, err
+		}
+		if delta != nil {
+			deltas = append(deltas, delta)
+		}
+	}
+
+	// Labels
+	switch {
+	case len(left.Labels) == 0 && len(right.Labels) == 0:
+	case len(left.Labels) == 0:
+		value, err := diff.JSONValue(right.Labels)
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, diff.NewAdded(diff.Name("labels"), value))
+	case len(right.Labels) == 0:
+		value, err := diff.JSONValue(left.Labels)
+		if err != nil {
+			return nil, err
+		}
+		deltas = append(deltas, diff.NewDeleted(diff.Name("labels"), value))
+	default:
+		changed = len(left.Labels) != len(right.Labels) || (left.Labels == nil) != (right.Labels == nil)
+		for key, value := range left.Labels {
+			if changed {
+				break
+			}
+			other, ok := right.Labels[key]
+			changed = !ok || value != other
+		}
+		if changed {
+			delta, err := recordDiffer.CompareAt(diff.Name("labels"), left.Labels, right.Labels)
+			if err != nil {
+				return nil, err
+			}
+			if delta != nil {
+				deltas = append(deltas, delta)
+			}
+		}
+	}
+
+	// Name
+	if left.Name != right.Name {
+		delta, err := recordDiffer.CompareAt(diff.Name("name"), string(left.Name), string(right.Name))
+		if err != nil {
+			return nil, err
+		}
+		if delta != nil {
+			deltas = append(deltas, delta)
+		}
+	}
+
+	// Parent
+	switch {
+	case left.Parent == nil && right.Parent == nil:
+	case left.Parent == nil:
+		value, err := diff.JSONValue(right.Parent)
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, diff.NewAdded(diff.Name("parent"), value))
+	case right.Parent == nil:
+		value, err := diff.JSONValue(left.Parent)
+		if err != nil {
+			return nil, err
+		}
+		deltas = append(deltas, diff.NewDeleted(diff.Name("parent"), value))
+	default:
+		if left.Parent != nil && right.Parent != nil {
+			childDeltas, err := itemDeltas(left.Parent, right.Parent)
+			if err != nil {
+				return nil, err
+			}
+			if len(childDeltas) > 0 {
+				deltas = append(deltas, diff.NewObject(diff.Name("parent"), childDeltas))
+			}
+		} else if left.Parent != right.Parent {
+			delta, err := recordDiffer.CompareAt(diff.Name("parent"), left.Parent, right.Parent)
+			if err != nil {
+				return nil, err
+			}
+			if delta != nil {
+				deltas = append(deltas, delta)
+			}
+		}
+	}
+
+	// Raw
+	switch {
+	case len(left.Raw) == 0 && len(right.Raw) == 0:
+	case len(left.Raw) == 0:
+		value, err := diff.JSONValue(right.Raw)
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, diff.NewAdded(diff.Name("raw"), value))
+	case len(right.Raw) == 0:
+		value, err := diff.JSONValue(left.Raw)
+		if err != nil {
+			return nil, err
+		}
+		deltas = append(deltas, diff.NewDeleted(diff.Name("raw"), value))
+	default:
+		{
+			delta, err := recordDiffer.CompareAt(diff.Name("raw"), left.Raw, right.Raw)
+			if err != nil {
+				return nil, err
+			}
+			if delta != nil {
+				deltas = append(deltas, delta)
+			}
+		}
+	}
+
+	// Score
+	if left.Score != right.Score {
+		deltas = append(deltas, diff.NewModified(diff.Name("score"), diff.Float32Value(left.Score), diff.Float32Value(right.Score)))
+	}
+
+	// Status
+	switch {
+	case left.Status == "" && right.Status == "":
+	case left.Status == "":
+		value := string(right.Status)
+		added = append(added, diff.NewAdded(diff.Name("status"), value))
+	case right.Status == "":
+		value := string(left.Status)
+		deltas = append(deltas, diff.NewDeleted(diff.Name("status"), value))
+	default:
+		if left.Status != right.Status {
+			delta, err := recordDiffer.CompareAt(diff.Name("status"), string(left.Status), string(right.Status))
+			if err != nil {
+				return nil, err
+			}
+			if delta != nil {
+				deltas = append(deltas, delta)
+			}
+		}
+	}
+
+	// Tags
+	changed = len(left.Tags) != len(right.Tags) || (left.Tags == nil) != (right.Tags == nil)
+	for i := 0; !changed && i < len(left.Tags); i++ {
+		changed = left.Tags[i] != right.Tags[i]
+	}
+	if changed {
+		delta, err := recordDiffer.CompareAt(diff.Name("tags"), left.Tags, right.Tags)
+		if err != nil {
+			return nil, err
+		}
+		if delta != nil {
+			deltas = append(deltas, delta)
+		}
+	}
+
+	// Window
+	if left.Window != right.Window {
+		delta, err := recordDiffer.CompareAt(diff.Name("window"), left.Window, right.Window)
+		if err != nil {
+			return nil, err
+		}
+		if delta != nil {
+			deltas = append(deltas, delta)
+		}
+	}
+	return append(deltas, added...), nil
+}
+
+// DiffItem compares two Item values as their JSON encodings and returns a Diff.
+// The values must not be nil.
+func DiffItem(left, right *Item) (diff.Diff, error) {
+	deltas, err := itemDeltas(left, right)
+	if err != nil {
+		return nil, err
+	}
+	return diff.NewDiff(deltas), nil
+}
+
+func itemDeltas(left, right *Item) ([]diff.Delta, error) {
+	var deltas, added []diff.Delta
+
+	// Count
+	switch {
+	case left.Count == 0 && right.Count == 0:
+	case left.Count == 0:
+		value := float64(right.Count)
+		added = append(added, diff.NewAdded(diff.Name("count"), value))
+	case right.Count == 0:
+		value := float64(left.Count)
+		deltas = append(deltas, diff.NewDeleted(diff.Name("count"), value))
+	default:
+		if left.Count != right.Count {
+			deltas = append(deltas, diff.NewModified(diff.Name("count"), float64(left.Count), float64(right.Count)))
+		}
+	}
+
+	// Key
+	if left.Key != right.Key {
+		delta, err := recordDiffer.CompareAt(diff.Name("key"), string(left.Key), string(right.Key))
+		if err != nil {
+			return nil, err
+		}
+		if delta != nil {
+			deltas = append(deltas, delta)
+		}
+	}
+	return append(deltas, added...), nil
+}
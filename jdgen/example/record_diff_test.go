@@ -0,0 +1,95 @@
+package example_test
+
+import (
+	. "github.com/yudai/gojsondiff/jdgen/example"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+var _ = Describe("Generated differs", func() {
+	record := func() *Record {
+		return &Record{
+			ID:        1,
+			Name:      "record",
+			Score:     0.5,
+			Tags:      []string{"a", "b"},
+			Labels:    map[string]string{"env": "dev"},
+			Window:    [2]int{1, 2},
+			Item:      Item{Key: "item", Count: 1},
+			Items:     []Item{{Key: "a"}, {Key: "b"}},
+			Extra:     map[string]interface{}{"k": 1.0},
+			Raw:       json.RawMessage(`{"raw":true}`),
+			CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		}
+	}
+
+	expectSameAsCompareValues := func(left, right *Record) {
+		expected, err := diff.New().CompareValues(left, right)
+		Expect(err).To(BeNil())
+		actual, err := DiffRecord(left, right)
+		Expect(err).To(BeNil())
+		Expect(actual.Deltas()).To(Equal(expected.Deltas()))
+	}
+
+	It("finds no differences in the same records", func() {
+		d, err := DiffRecord(record(), record())
+		Expect(err).To(BeNil())
+		Expect(d.Modified()).To(BeFalse())
+	})
+
+	It("returns the same deltas as CompareValues", func() {
+		left, right := record(), record()
+		right.ID = 2
+		right.Score = 0.1
+		right.Name = strings.Repeat("long text ", 5)
+		left.Name = strings.Repeat("long test ", 5)
+		right.Status = "active"
+		right.Active = true
+		right.Tags = []string{"b", "c"}
+		right.Labels = nil
+		right.Window[1] = 3
+		right.Item.Count = 0
+		right.Parent = &Item{Key: "parent"}
+		right.Items[1].Count = 2
+		right.Extra = "extra"
+		right.Raw = json.RawMessage(`{"raw":false}`)
+		right.CreatedAt = right.CreatedAt.Add(time.Hour)
+		right.Internal = "ignored"
+		right.Cache = "ignored"
+		expectSameAsCompareValues(left, right)
+
+		left.Parent = &Item{Key: "old", Count: 1}
+		expectSameAsCompareValues(left, right)
+		expectSameAsCompareValues(right, left)
+	})
+
+	It("produces diffs that can be applied", func() {
+		left, right := record(), record()
+		right.Tags = append(right.Tags, "c")
+		right.Item.Key = "changed"
+		d, err := DiffRecord(left, right)
+		Expect(err).To(BeNil())
+
+		leftValue, _ := diff.JSONValue(left)
+		rightValue, _ := diff.JSONValue(right)
+		patched := leftValue.(map[string]interface{})
+		diff.New().ApplyPatch(patched, d)
+		Expect(patched).To(Equal(rightValue))
+	})
+
+	It("compares unchanged values without allocations", func() {
+		left, right := &Item{Key: "a", Count: 1}, &Item{Key: "a", Count: 1}
+		allocs := testing.AllocsPerRun(100, func() {
+			DiffItem(left, right)
+		})
+		Expect(allocs).To(BeNumerically("<=", 1)) // the Diff
+	})
+})
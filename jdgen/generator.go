@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func generate(dir string, names []string, output string) error {
+	pkg, err := loadPackage(dir, filepath.Base(output))
+	if err != nil {
+		return err
+	}
+
+	g := &generator{
+		types:  map[string]*types.Named{},
+		differ: lowerFirst(names[0]) + "Differ",
+	}
+	for _, name := range names {
+		obj := pkg.Scope().Lookup(name)
+		if obj == nil {
+			return fmt.Errorf("type %s not found in %s", name, dir)
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			return fmt.Errorf("%s is not a named type", name)
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			return fmt.Errorf("%s is not a struct type", name)
+		}
+		g.types[name] = named
+	}
+
+	g.printf("// Code generated by jdgen; DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", pkg.Name())
+	g.printf("import diff %q\n\n", "github.com/yudai/gojsondiff")
+	g.printf("var %s = diff.New()\n", g.differ)
+	for _, name := range names {
+		if err := g.generateType(g.types[name]); err != nil {
+			return err
+		}
+	}
+
+	source, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("invalid generated code: %s", err)
+	}
+	return ioutil.WriteFile(output, source, 0644)
+}
+
+// loadPackage type-checks the package in the directory, except the output
+// file. Type errors are ignored, as the output file may be stale.
+func loadPackage(dir string, output string) (*types.Package, error) {
+	info, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	files := []*ast.File{}
+	for _, name := range info.GoFiles {
+		if name == output {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	config := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	pkg, _ := config.Check(info.ImportPath, fset, files, nil)
+	return pkg, nil
+}
+
+type generator struct {
+	types  map[string]*types.Named // types to generate
+	differ string                  // name of the Differ variable
+	buf    bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// A field is a field of a struct encoded by encoding/json.
+type field struct {
+	goName    string
+	name      string
+	omitEmpty bool
+	typ       types.Type
+}
+
+func (g *generator) generateType(named *types.Named) error {
+	name := named.Obj().Name()
+	fields, err := structFields(named)
+	if err != nil {
+		return err
+	}
+
+	exported := "diff" + upperFirst(name)
+	if named.Obj().Exported() {
+		exported = "Diff" + name
+	}
+	g.printf("\n// %s compares two %s values as their JSON encodings and returns a Diff.\n", exported, name)
+	g.printf("// The values must not be nil.\n")
+	g.printf("func %s(left, right *%s) (diff.Diff, error) {\n", exported, name)
+	g.printf("deltas, err := %s(left, right)\n", deltasFunc(name))
+	g.printf("if err != nil {\nreturn nil, err\n}\n")
+	g.printf("return diff.NewDiff(deltas), nil\n}\n")
+
+	g.printf("\nfunc %s(left, right *%s) ([]diff.Delta, error) {\n", deltasFunc(name), name)
+	g.printf("var deltas, added []diff.Delta\n")
+	for _, f := range fields {
+		if g.usesChanged(f.typ) {
+			g.printf("var changed bool\n")
+			break
+		}
+	}
+	for _, f := range fields {
+		g.generateField(f)
+	}
+	g.printf("return append(deltas, added...), nil\n}\n")
+	return nil
+}
+
+func (g *generator) generateField(f field) {
+	left, right := "left."+f.goName, "right."+f.goName
+	position := fmt.Sprintf("diff.Name(%q)", f.name)
+
+	g.printf("\n// %s\n", f.goName)
+	if f.omitEmpty && canBeEmpty(f.typ) {
+		g.printf("switch {\n")
+		g.printf("case %s && %s:\n", emptyExpr(f.typ, left), emptyExpr(f.typ, right))
+		g.printf("case %s:\n", emptyExpr(f.typ, left))
+		g.generateValue("value", f.typ, right)
+		g.printf("added = append(added, diff.NewAdded(%s, value))\n", position)
+		g.printf("case %s:\n", emptyExpr(f.typ, right))
+		g.generateValue("value", f.typ, left)
+		g.printf("deltas = append(deltas, diff.NewDeleted(%s, value))\n", position)
+		g.printf("default:\n")
+		g.generateCompare(f.typ, position, left, right)
+		g.printf("}\n")
+		return
+	}
+	g.generateCompare(f.typ, position, left, right)
+}
+
+// generateValue converts the expression into the data model of
+// encoding/json and assigns it to the variable.
+func (g *generator) generateValue(variable string, t types.Type, expr string) {
+	if kind := g.kindOf(t); kind == kindBasic || kind == kindString {
+		g.printf("%s := %s\n", variable, basicValue(t, expr))
+		return
+	}
+	g.printf("%s, err := diff.JSONValue(%s)\n", variable, expr)
+	g.printf("if err != nil {\nreturn nil, err\n}\n")
+}
+
+func (g *generator) generateCompare(t types.Type, position, left, right string) {
+	switch g.kindOf(t) {
+	case kindBasic:
+		g.printf("if %s != %s {\n", left, right)
+		g.printf("deltas = append(deltas, diff.NewModified(%s, %s, %s))\n", position, basicValue(t, left), basicValue(t, right))
+		g.printf("}\n")
+	case kindString:
+		g.printf("if %s != %s {\n", left, right)
+		g.generateCompareAt(position, "string("+left+")", "string("+right+")")
+		g.printf("}\n")
+	case kindStruct:
+		g.printf("{\n")
+		g.generateNested(t, position, "&"+left, "&"+right)
+		g.printf("}\n")
+	case kindStructPtr:
+		g.printf("if %s != nil && %s != nil {\n", left, right)
+		g.generateNested(t.(*types.Pointer).Elem(), position, left, right)
+		g.printf("} else if %s != %s {\n", left, right)
+		g.generateCompareAt(position, left, right)
+		g.printf("}\n")
+	case kindEqual:
+		g.printf("if !%s.Equal(%s) {\n", left, right)
+		g.generateCompareAt(position, left, right)
+		g.printf("}\n")
+	case kindSlice:
+		if _, ok := t.Underlying().(*types.Array); ok {
+			g.printf("if %s != %s {\n", left, right)
+			g.generateCompareAt(position, left, right)
+			g.printf("}\n")
+			return
+		}
+		g.printf("changed = len(%s) != len(%s) || (%s == nil) != (%s == nil)\n", left, right, left, right)
+		g.printf("for i := 0; !changed && i < len(%s); i++ {\n", left)
+		g.printf("changed = %s[i] != %s[i]\n", left, right)
+		g.printf("}\n")
+		g.printf("if changed {\n")
+		g.generateCompareAt(position, left, right)
+		g.printf("}\n")
+	case kindMap:
+		g.printf("changed = len(%s) != len(%s) || (%s == nil) != (%s == nil)\n", left, right, left, right)
+		g.printf("for key, value := range %s {\n", left)
+		g.printf("if changed {\nbreak\n}\n")
+		g.printf("other, ok := %s[key]\n", right)
+		g.printf("changed = !ok || value != other\n")
+		g.printf("}\n")
+		g.printf("if changed {\n")
+		g.generateCompareAt(position, left, right)
+		g.printf("}\n")
+	default:
+		g.printf("{\n")
+		g.generateCompareAt(position, left, right)
+		g.printf("}\n")
+	}
+}
+
+func (g *generator) generateNested(t types.Type, position, left, right string) {
+	g.printf("childDeltas, err := %s(%s, %s)\n", deltasFunc(t.(*types.Named).Obj().Name()), left, right)
+	g.printf("if err != nil {\nreturn nil, err\n}\n")
+	g.printf("if len(childDeltas) > 0 {\n")
+	g.printf("deltas = append(deltas, diff.NewObject(%s, childDeltas))\n", position)
+	g.printf("}\n")
+}
+
+func (g *generator) generateCompareAt(position, left, right string) {
+	g.printf("delta, err := %s.CompareAt(%s, %s, %s)\n", g.differ, position, left, right)
+	g.printf("if err != nil {\nreturn nil, err\n}\n")
+	g.printf("if delta != nil {\ndeltas = append(deltas, delta)\n}\n")
+}
+
+// usesChanged reports whether the comparison of the type uses the variable
+// changed.
+func (g *generator) usesChanged(t types.Type) bool {
+	switch g.kindOf(t) {
+	case kindSlice:
+		_, ok := t.Underlying().(*types.Array)
+		return !ok
+	case kindMap:
+		return true
+	}
+	return false
+}
+
+type kind int
+
+const (
+	kindBasic     kind = iota // booleans and numbers
+	kindString                // strings, which can have text diffs
+	kindStruct                // generated struct types
+	kindStructPtr             // pointers to generated struct types
+	kindEqual                 // types with an Equal method, such as time.Time
+	kindSlice                 // slices and arrays of basic types
+	kindMap                   // maps of basic types
+	kindOther                 // compared with reflection
+)
+
+func (g *generator) kindOf(t types.Type) kind {
+	if named, ok := t.(*types.Named); ok && g.types[named.Obj().Name()] == named {
+		return kindStruct
+	}
+	if pointer, ok := t.(*types.Pointer); ok {
+		if named, ok := pointer.Elem().(*types.Named); ok && g.types[named.Obj().Name()] == named {
+			return kindStructPtr
+		}
+	}
+	if isBasic(t) {
+		if t.Underlying().(*types.Basic).Info()&types.IsString != 0 {
+			return kindString
+		}
+		return kindBasic
+	}
+	if hasMethod(t, "MarshalJSON") || hasMethod(t, "MarshalText") {
+		if hasEqual(t) {
+			return kindEqual
+		}
+		return kindOther
+	}
+	if hasEqual(t) {
+		return kindEqual
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		if isBasic(u.Elem()) {
+			return kindSlice
+		}
+	case *types.Array:
+		if isBasic(u.Elem()) {
+			return kindSlice
+		}
+	case *types.Map:
+		if isBasic(u.Key()) && isBasic(u.Elem()) {
+			return kindMap
+		}
+	}
+	return kindOther
+}
+
+// isBasic reports whether the type is encoded as a JSON string, number or
+// boolean by its underlying type.
+func isBasic(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok || basic.Info()&(types.IsBoolean|types.IsString|types.IsInteger|types.IsFloat) == 0 {
+		return false
+	}
+	if named, ok := t.(*types.Named); ok {
+		obj := named.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "encoding/json" && obj.Name() == "Number" {
+			return false
+		}
+	}
+	return !hasMethod(t, "MarshalJSON") && !hasMethod(t, "MarshalText")
+}
+
+func basicValue(t types.Type, expr string) string {
+	switch t {
+	case types.Typ[types.Bool], types.Typ[types.String], types.Typ[types.Float64]:
+		return expr
+	}
+	info := t.Underlying().(*types.Basic).Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		return "bool(" + expr + ")"
+	case info&types.IsString != 0:
+		return "string(" + expr + ")"
+	case t == types.Typ[types.Float32]:
+		// float32 is encoded with its own precision
+		return "diff.Float32Value(" + expr + ")"
+	case t.Underlying() == types.Typ[types.Float32]:
+		return "diff.Float32Value(float32(" + expr + "))"
+	}
+	return "float64(" + expr + ")"
+}
+
+func hasMethod(t types.Type, name string) bool {
+	return types.NewMethodSet(types.NewPointer(t)).Lookup(nil, name) != nil
+}
+
+// hasEqual reports whether the type has a method Equal(T) bool.
+func hasEqual(t types.Type) bool {
+	selection := types.NewMethodSet(types.NewPointer(t)).Lookup(nil, "Equal")
+	if selection == nil {
+		return false
+	}
+	signature := selection.Type().(*types.Signature)
+	return signature.Params().Len() == 1 &&
+		types.Identical(signature.Params().At(0).Type(), t) &&
+		signature.Results().Len() == 1 &&
+		types.Identical(signature.Results().At(0).Type(), types.Typ[types.Bool])
+}
+
+func canBeEmpty(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Basic, *types.Slice, *types.Map, *types.Array, *types.Pointer, *types.Interface:
+		return true
+	}
+	return false
+}
+
+func emptyExpr(t types.Type, expr string) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "!" + expr
+		case u.Info()&types.IsString != 0:
+			return expr + ` == ""`
+		}
+		return expr + " == 0"
+	case *types.Pointer, *types.Interface:
+		return expr + " == nil"
+	}
+	return "len(" + expr + ") == 0"
+}
+
+// structFields returns the fields of the struct type sorted by their JSON
+// names, which is the order of Deltas returned by Differ.
+func structFields(named *types.Named) ([]field, error) {
+	st := named.Underlying().(*types.Struct)
+	fields := []field{}
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		jsonTag := tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, options := jsonTag, ""
+		if i := strings.Index(jsonTag, ","); i >= 0 {
+			name, options = jsonTag[:i], jsonTag[i+1:]
+		}
+		if v.Embedded() && name == "" {
+			return nil, fmt.Errorf("%s.%s: embedded fields are not supported", named.Obj().Name(), v.Name())
+		}
+		if !v.Exported() {
+			continue
+		}
+		switch directive := tag.Get("diff"); directive {
+		case "":
+		case "-":
+			continue
+		default:
+			return nil, fmt.Errorf("%s.%s: diff tag %q is not supported", named.Obj().Name(), v.Name(), directive)
+		}
+
+		f := field{goName: v.Name(), name: name, typ: v.Type()}
+		if f.name == "" {
+			f.name = v.Name()
+		}
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "omitempty":
+				f.omitEmpty = true
+			case "string":
+				return nil, fmt.Errorf("%s.%s: the string option is not supported", named.Obj().Name(), v.Name())
+			}
+		}
+		fields = append(fields, f)
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+	return fields, nil
+}
+
+func deltasFunc(name string) string {
+	return lowerFirst(name) + "Deltas"
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,69 @@
+// Command jdgen generates typed differs for Go struct types.
+//
+// For each type T, it generates a function
+//
+//	func DiffT(left, right *T) (diff.Diff, error)
+//
+// that returns the same Deltas as Differ.CompareValues, but compares fields
+// of basic types, nested generated types, slices and maps of basic types, and
+// types with an Equal method without reflection nor allocations. Changed
+// values are converted into the data model of encoding/json, so the Diff
+// works with all formatters and ApplyPatch.
+//
+// Use it with go generate:
+//
+//	//go:generate jdgen -type Record,Item
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "jdgen"
+	app.Usage = "Generate typed JSON differs for Go structs"
+	app.Version = "0.0.1"
+	app.ArgsUsage = "[directory]"
+
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "type, t",
+			Usage: "Comma-separated names of struct types",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Output file name (default <type>_diff.go in the directory)",
+		},
+	}
+
+	app.Action = func(c *cli.Context) {
+		if c.String("type") == "" {
+			fmt.Print("No types given.\n\n")
+			fmt.Printf("Usage: %s -type T [directory]\n", app.Name)
+			os.Exit(1)
+		}
+		names := strings.Split(c.String("type"), ",")
+
+		dir := "."
+		if len(c.Args()) > 0 {
+			dir = c.Args()[0]
+		}
+		output := c.String("output")
+		if output == "" {
+			output = filepath.Join(dir, strings.ToLower(names[0])+"_diff.go")
+		}
+
+		if err := generate(dir, names, output); err != nil {
+			fmt.Printf("jdgen: %s\n", err)
+			os.Exit(2)
+		}
+	}
+
+	app.Run(os.Args)
+}
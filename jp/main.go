@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/urfave/cli"
 
@@ -17,11 +19,33 @@ func main() {
 	app.Usage = "JSON Diff"
 	app.Version = "0.0.2"
 
-	app.Flags = []cli.Flag{}
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "input, i",
+			Value:  "auto",
+			Usage:  "Input Format (auto, json, json5, yaml). auto detects JSON5 and YAML by the file extension",
+			EnvVar: "INPUT_FORMAT",
+		},
+		cli.BoolFlag{
+			Name:  "keep-order",
+			Usage: "Keep the order of keys in JSON input instead of sorting them",
+		},
+		cli.BoolFlag{
+			Name:   "lenient, l",
+			Usage:  "Accept comments, trailing commas and unquoted keys in JSON input (same as --input json5)",
+			EnvVar: "LENIENT",
+		},
+		cli.StringFlag{
+			Name:   "output, o",
+			Value:  "auto",
+			Usage:  "Output Format (auto, json, yaml). auto uses the input format",
+			EnvVar: "OUTPUT_FORMAT",
+		},
+	}
 
 	app.Action = func(c *cli.Context) {
 		if len(c.Args()) < 2 {
-			fmt.Println("Not enough arguments.\n")
+			fmt.Print("Not enough arguments.\n\n")
 			fmt.Printf("Usage: %s diff json_file\n", app.Name)
 			os.Exit(1)
 		}
@@ -51,13 +75,49 @@ func main() {
 			os.Exit(2)
 		}
 
+		inputYAML := isYAML(c.String("input"), jsonFilePath)
+		outputYAML := inputYAML
+		if output := c.String("output"); output != "auto" {
+			outputYAML = output == "yaml"
+		}
+
 		// Load JSON
-		var jsonObject map[string]interface{}
-		json.Unmarshal(jsonFile, &jsonObject)
+		differ := diff.New()
+		var jsonObject interface{}
+		var stream bool
+		if inputYAML {
+			var documents []interface{}
+			documents, err = diff.UnmarshalYAML(jsonFile)
+			jsonObject, stream = yamlRoot(documents, diffObject)
+		} else {
+			// comments in the input are not preserved in the output
+			differ.Lenient = isJSON5(c, jsonFilePath)
+			differ.PreserveKeyOrder = c.Bool("keep-order")
+			jsonObject, err = differ.Unmarshal(jsonFile)
+		}
+		if err != nil {
+			fmt.Printf("Failed to load file '%s': %s\n", jsonFilePath, err.Error())
+			os.Exit(3)
+		}
 
 		// Apply
-		differ := diff.New()
-		differ.ApplyPatch(jsonObject, diffObject)
+		switch o := jsonObject.(type) {
+		case map[string]interface{}:
+			differ.ApplyPatch(o, diffObject)
+		case *diff.OrderedMap:
+			differ.ApplyPatchToOrderedMap(o, diffObject)
+		case []interface{}:
+			jsonObject = differ.ApplyPatchToArray(o, diffObject)
+		default:
+			fmt.Printf("Failed to apply the diff: '%s' is not an object or an array\n", jsonFilePath)
+			os.Exit(3)
+		}
+
+		if outputYAML {
+			pachedYaml, _ := marshalYAML(jsonObject, stream)
+			fmt.Print(string(pachedYaml))
+			return
+		}
 
 		pachedJson, _ := json.MarshalIndent(jsonObject, "", "  ")
 		fmt.Println(string(pachedJson))
@@ -65,3 +125,68 @@ func main() {
 
 	app.Run(os.Args)
 }
+
+// yamlRoot returns the value of the YAML documents to patch with the Diff,
+// and whether the value is the array of the documents of a stream. A single
+// document is patched as a stream when the Diff is a diff of arrays and the
+// document is not a sequence, as jd compares a single document with a stream
+// of multiple documents.
+func yamlRoot(documents []interface{}, d diff.Diff) (root interface{}, stream bool) {
+	if len(documents) != 1 {
+		return documents, true
+	}
+	if _, ok := documents[0].([]interface{}); !ok && isArrayDiff(d) {
+		return documents, true
+	}
+	return documents[0], false
+}
+
+// isArrayDiff reports whether the Diff is generated from arrays.
+func isArrayDiff(d diff.Diff) bool {
+	for _, delta := range d.Deltas() {
+		var position diff.Position
+		switch delta := delta.(type) {
+		case diff.PostDelta:
+			position = delta.PostPosition()
+		case diff.PreDelta:
+			position = delta.PrePosition()
+		}
+		_, isIndex := position.(diff.Index)
+		return isIndex
+	}
+	return false
+}
+
+// marshalYAML encodes the patched value as a YAML stream. The value is an
+// array of documents when the input was a stream of multiple documents.
+func marshalYAML(value interface{}, multiple bool) ([]byte, error) {
+	if documents, ok := value.([]interface{}); ok && multiple {
+		// keep multiple documents as a stream
+		return diff.MarshalYAML(documents...)
+	}
+	return diff.MarshalYAML(value)
+}
+
+func isYAML(format string, path string) bool {
+	switch format {
+	case "yaml":
+		return true
+	case "auto":
+		extension := strings.ToLower(filepath.Ext(path))
+		return extension == ".yaml" || extension == ".yml"
+	}
+	return false
+}
+
+func isJSON5(c *cli.Context, path string) bool {
+	switch c.String("input") {
+	case "json5", "jsonc":
+		return true
+	case "auto":
+		extension := strings.ToLower(filepath.Ext(path))
+		if extension == ".json5" || extension == ".jsonc" {
+			return true
+		}
+	}
+	return c.Bool("lenient")
+}
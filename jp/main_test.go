@@ -0,0 +1,47 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	diff "github.com/yudai/gojsondiff"
+)
+
+var _ = Describe("YAML output", func() {
+	It("writes a JSON array as a sequence", func() {
+		output, err := marshalYAML([]interface{}{"a", "b"}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(output)).To(Equal("- a\n- b\n"))
+	})
+
+	It("keeps multiple YAML documents as a stream", func() {
+		output, err := marshalYAML([]interface{}{"a", "b"}, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(output)).To(Equal("a\n---\nb\n"))
+	})
+})
+
+var _ = Describe("YAML input", func() {
+	It("patches a single document", func() {
+		documents := []interface{}{map[string]interface{}{"key": "value"}}
+		d := diff.New().CompareObjects(documents[0].(map[string]interface{}), map[string]interface{}{"key": "other"})
+		root, stream := yamlRoot(documents, d)
+		Expect(root).To(Equal(documents[0]))
+		Expect(stream).To(BeFalse())
+	})
+
+	It("patches a single document as a stream with diffs of streams", func() {
+		a, b := []byte("key: value\n"), []byte("key: value\n---\nkey: other\n")
+		d, err := diff.New().CompareYAML(a, b)
+		Expect(err).NotTo(HaveOccurred())
+
+		documents, err := diff.UnmarshalYAML(a)
+		Expect(err).NotTo(HaveOccurred())
+		root, stream := yamlRoot(documents, d)
+		Expect(stream).To(BeTrue())
+		patched := diff.New().ApplyPatchToArray(root.([]interface{}), d)
+		output, err := marshalYAML(patched, stream)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(output)).To(Equal(string(b)))
+	})
+})
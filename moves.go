@@ -0,0 +1,84 @@
+package gojsondiff
+
+import (
+	"container/list"
+	"sort"
+)
+
+// WithMoveSimilarityThreshold makes the Differ report array elements that
+// moved and changed as Moved with the Delta of the element, instead of
+// Deleted and Added. The threshold, from 0 to 1, is the minimum similarity
+// of objects and arrays paired as moved. Zero disables the detection, which
+// is the default, and only elements moved without changes are reported as
+// Moved.
+func WithMoveSimilarityThreshold(threshold float64) Option {
+	return func(differ *Differ) {
+		differ.moveThreshold = threshold
+	}
+}
+
+type moveCandidate struct {
+	deleted, added *list.Element
+	similarity     float64
+	delta          Delta
+}
+
+// findModifiedMoves pairs objects and arrays deleted from a position and
+// added to another position by their similarities, and removes the pairs
+// from the lists. Elements at the same position between LCS elements are
+// left to maximizeSimilarities, which reports them as modified. When bucketed,
+// deleted elements are compared only with a limited number of added elements
+// of the same shape.
+func (differ *Differ) findModifiedMoves(path []Position, maybeDeleted, maybeAdded *list.List, elem *valuePolicy, bucketed bool) (deltas []Delta) {
+	var shapes map[string][]*list.Element
+	all := make([]*list.Element, 0, maybeAdded.Len())
+	if bucketed {
+		shapes = shapeBuckets(maybeAdded)
+	} else {
+		for addElement := maybeAdded.Front(); addElement != nil; addElement = addElement.Next() {
+			all = append(all, addElement)
+		}
+	}
+
+	candidates := make([]moveCandidate, 0)
+	for delElement := maybeDeleted.Front(); delElement != nil; delElement = delElement.Next() {
+		del := delElement.Value.(maybe)
+		addElements := all
+		if bucketed {
+			addElements = shapes[valueShape(del.plain)]
+			if len(addElements) > maxMoveCandidates {
+				addElements = addElements[:maxMoveCandidates]
+			}
+		}
+		for _, addElement := range addElements {
+			add := addElement.Value.(maybe)
+			if del.lcsIndex == add.lcsIndex {
+				continue
+			}
+			_, delta := differ.compareValues(path, Index(add.index), del.item, add.item, elem)
+			switch delta.(type) {
+			case *Object, *Array, *Embedded:
+				if similarity := delta.Similarity(); similarity >= differ.moveThreshold {
+					candidates = append(candidates, moveCandidate{delElement, addElement, similarity, delta})
+				}
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+
+	paired := map[*list.Element]bool{}
+	deltas = make([]Delta, 0)
+	for _, candidate := range candidates {
+		if paired[candidate.deleted] || paired[candidate.added] {
+			continue
+		}
+		paired[candidate.deleted], paired[candidate.added] = true, true
+		del := candidate.deleted.Value.(maybe)
+		deltas = append(deltas, NewMoved(Index(del.index), Index(candidate.added.Value.(maybe).index), elem.hide(del.item), candidate.delta))
+		maybeDeleted.Remove(candidate.deleted)
+		maybeAdded.Remove(candidate.added)
+	}
+	return deltas
+}
@@ -0,0 +1,52 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Moved and modified elements", func() {
+	item := func(id float64, name string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "name": name, "kind": "item", "enabled": true}
+	}
+	left := func() []interface{} {
+		return []interface{}{item(1, "a"), item(2, "b"), item(3, "c"), item(4, "d")}
+	}
+	right := func() []interface{} {
+		return []interface{}{item(4, "D"), item(1, "a"), item(2, "b"), item(3, "c")}
+	}
+
+	It("reports moved elements with their changes", func() {
+		deltas := New(WithMoveSimilarityThreshold(0.5)).CompareArrays(left(), right()).Deltas()
+		Expect(deltas).To(HaveLen(1))
+		moved := deltas[0].(*Moved)
+		Expect(moved.PrePosition()).To(Equal(Index(3)))
+		Expect(moved.PostPosition()).To(Equal(Index(0)))
+		Expect(moved.Delta).To(Equal(NewObject(Index(0), []Delta{NewModified(Name("name"), "d", "D")})))
+	})
+
+	It("keeps dissimilar elements deleted and added", func() {
+		deltas := New().CompareArrays(left(), right()).Deltas()
+		Expect(deltas).To(ConsistOf(NewDeleted(Index(3), item(4, "d")), NewAdded(Index(0), item(4, "D"))))
+
+		deltas = New(WithMoveSimilarityThreshold(0.99)).CompareArrays(left(), right()).Deltas()
+		Expect(deltas).To(HaveLen(2))
+	})
+
+	It("patches moved elements", func() {
+		d := New(WithMoveSimilarityThreshold(0.5)).CompareArrays(left(), right())
+		Expect(New().ApplyPatchToArray(left(), d)).To(Equal(right()))
+
+		type record struct {
+			ID      int    `json:"id"`
+			Name    string `json:"name"`
+			Kind    string `json:"kind"`
+			Enabled bool   `json:"enabled"`
+		}
+		value := []record{{1, "a", "item", true}, {2, "b", "item", true}, {3, "c", "item", true}, {4, "d", "item", true}}
+		Expect(New().ApplyPatchToValue(&value, d)).To(Succeed())
+		Expect(value).To(Equal([]record{{4, "D", "item", true}, {1, "a", "item", true}, {2, "b", "item", true}, {3, "c", "item", true}}))
+	})
+})
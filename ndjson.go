@@ -0,0 +1,364 @@
+package gojsondiff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RecordChange describes how a record in a NDJSON stream has changed.
+type RecordChange string
+
+const (
+	RecordAdded    = RecordChange("added")
+	RecordDeleted  = RecordChange("deleted")
+	RecordModified = RecordChange("modified")
+)
+
+// A RecordDiff describes a change of a record between two NDJSON streams.
+type RecordDiff struct {
+	Change RecordChange
+	// Key is the key of the record, nil when records are matched by line
+	Key []interface{}
+	// LeftLine and RightLine are the 1-based line numbers of the record,
+	// 0 when the record does not exist on the side
+	LeftLine  int
+	RightLine int
+	Left      map[string]interface{}
+	Right     map[string]interface{}
+	// Diff holds the differences of a modified record
+	Diff Diff
+}
+
+// KeyString returns the key as a comma separated list of JSON values.
+func (r RecordDiff) KeyString() string {
+	return recordKeyString(r.Key)
+}
+
+// A RecordKeyFunc returns the key that identifies a record.
+// A key can consist of multiple values.
+type RecordKeyFunc func(record map[string]interface{}) ([]interface{}, error)
+
+// KeyByFields returns a RecordKeyFunc that makes keys from the values of the
+// fields. Nested fields are specified by dot separated names such as "user.id".
+func KeyByFields(fields ...string) RecordKeyFunc {
+	return func(record map[string]interface{}) ([]interface{}, error) {
+		key := make([]interface{}, len(fields))
+		for i, field := range fields {
+			var value interface{} = record
+			for _, name := range strings.Split(field, ".") {
+				object, ok := value.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("field %q not found", field)
+				}
+				if value, ok = object[name]; !ok {
+					return nil, fmt.Errorf("field %q not found", field)
+				}
+			}
+			key[i] = value
+		}
+		return key, nil
+	}
+}
+
+// RecordsConfig configures CompareRecords.
+type RecordsConfig struct {
+	// Key identifies records. Records are matched by line when nil.
+	Key RecordKeyFunc
+	// Sorted tells that both streams are sorted by Key in ascending order.
+	// Sorted streams are compared without holding records in memory,
+	// otherwise all records of the right stream are kept in memory.
+	// Numbers are ordered numerically and strings lexicographically.
+	Sorted bool
+}
+
+// CompareRecords compares two NDJSON (JSON Lines) streams record by record
+// and calls fn for each added, deleted or modified record.
+// Each line must be a JSON object, blank lines are ignored.
+// Matched records are compared with CompareObjects.
+// Records matched by key are held in memory for the right stream, so streams
+// larger than memory must be sorted by the key and compared with Sorted.
+func (differ *Differ) CompareRecords(
+	left io.Reader,
+	right io.Reader,
+	config RecordsConfig,
+	fn func(record RecordDiff) error,
+) error {
+	leftReader := differ.newRecordReader(left)
+	rightReader := differ.newRecordReader(right)
+	switch {
+	case config.Key == nil:
+		return differ.compareRecordsByLine(leftReader, rightReader, fn)
+	case config.Sorted:
+		return differ.compareSortedRecords(leftReader, rightReader, config.Key, fn)
+	default:
+		return differ.compareRecordsByKey(leftReader, rightReader, config.Key, fn)
+	}
+}
+
+func (differ *Differ) compareRecordsByLine(left, right *recordReader, fn func(RecordDiff) error) error {
+	for {
+		leftRecord, leftErr := left.next()
+		if leftErr != nil && leftErr != io.EOF {
+			return leftErr
+		}
+		rightRecord, rightErr := right.next()
+		if rightErr != nil && rightErr != io.EOF {
+			return rightErr
+		}
+
+		var err error
+		switch {
+		case leftErr == io.EOF && rightErr == io.EOF:
+			return nil
+		case leftErr == io.EOF:
+			err = fn(addedRecord(nil, right.line, rightRecord))
+		case rightErr == io.EOF:
+			err = fn(deletedRecord(nil, left.line, leftRecord))
+		default:
+			err = differ.emitIfModified(nil, left.line, leftRecord, right.line, rightRecord, fn)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (differ *Differ) compareRecordsByKey(left, right *recordReader, key RecordKeyFunc, fn func(RecordDiff) error) error {
+	type indexed struct {
+		key    []interface{}
+		line   int
+		record map[string]interface{}
+	}
+	index := map[string]*indexed{}
+	order := []string{}
+	for {
+		record, recordKey, err := right.nextWithKey(key)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		keyString := recordKeyString(recordKey)
+		if _, dup := index[keyString]; dup {
+			return right.errorf("duplicate key %s", keyString)
+		}
+		index[keyString] = &indexed{key: recordKey, line: right.line, record: record}
+		order = append(order, keyString)
+	}
+
+	seen := map[string]bool{}
+	for {
+		record, recordKey, err := left.nextWithKey(key)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		keyString := recordKeyString(recordKey)
+		if seen[keyString] {
+			return left.errorf("duplicate key %s", keyString)
+		}
+		seen[keyString] = true
+
+		if match, ok := index[keyString]; ok {
+			err = differ.emitIfModified(recordKey, left.line, record, match.line, match.record, fn)
+			delete(index, keyString)
+		} else {
+			err = fn(deletedRecord(recordKey, left.line, record))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, keyString := range order {
+		if added, ok := index[keyString]; ok {
+			if err := fn(addedRecord(added.key, added.line, added.record)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (differ *Differ) compareSortedRecords(left, right *recordReader, key RecordKeyFunc, fn func(RecordDiff) error) error {
+	leftRecord, leftKey, leftErr := left.nextSorted(key, nil)
+	rightRecord, rightKey, rightErr := right.nextSorted(key, nil)
+	for {
+		if leftErr != nil && leftErr != io.EOF {
+			return leftErr
+		}
+		if rightErr != nil && rightErr != io.EOF {
+			return rightErr
+		}
+
+		var err error
+		switch {
+		case leftErr == io.EOF && rightErr == io.EOF:
+			return nil
+		case leftErr == io.EOF || (rightErr == nil && compareRecordKeys(leftKey, rightKey) > 0):
+			err = fn(addedRecord(rightKey, right.line, rightRecord))
+			rightRecord, rightKey, rightErr = right.nextSorted(key, rightKey)
+		case rightErr == io.EOF || compareRecordKeys(leftKey, rightKey) < 0:
+			err = fn(deletedRecord(leftKey, left.line, leftRecord))
+			leftRecord, leftKey, leftErr = left.nextSorted(key, leftKey)
+		default:
+			err = differ.emitIfModified(leftKey, left.line, leftRecord, right.line, rightRecord, fn)
+			leftRecord, leftKey, leftErr = left.nextSorted(key, leftKey)
+			rightRecord, rightKey, rightErr = right.nextSorted(key, rightKey)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (differ *Differ) emitIfModified(
+	key []interface{},
+	leftLine int, left map[string]interface{},
+	rightLine int, right map[string]interface{},
+	fn func(RecordDiff) error,
+) error {
+	diff := differ.CompareObjects(left, right)
+	if !diff.Modified() {
+		return nil
+	}
+	return fn(RecordDiff{
+		Change:    RecordModified,
+		Key:       key,
+		LeftLine:  leftLine,
+		RightLine: rightLine,
+		Left:      left,
+		Right:     right,
+		Diff:      diff,
+	})
+}
+
+func addedRecord(key []interface{}, line int, record map[string]interface{}) RecordDiff {
+	return RecordDiff{Change: RecordAdded, Key: key, RightLine: line, Right: record}
+}
+
+func deletedRecord(key []interface{}, line int, record map[string]interface{}) RecordDiff {
+	return RecordDiff{Change: RecordDeleted, Key: key, LeftLine: line, Left: record}
+}
+
+type recordReader struct {
+	reader  *bufio.Reader
+	line    int
+	lenient bool
+}
+
+func (differ *Differ) newRecordReader(reader io.Reader) *recordReader {
+	return &recordReader{
+		reader:  bufio.NewReader(reader),
+		lenient: differ.Lenient,
+	}
+}
+
+// next returns the next record, or io.EOF at the end of the stream.
+func (r *recordReader) next() (map[string]interface{}, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(line) == 0 && err == io.EOF {
+			return nil, io.EOF
+		}
+		r.line++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var value interface{}
+		if r.lenient {
+			value, err = UnmarshalJSON5(line)
+		} else {
+			err = json.Unmarshal(line, &value)
+		}
+		if err != nil {
+			return nil, r.errorf("%s", err)
+		}
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, r.errorf("record is not a JSON object")
+		}
+		return record, nil
+	}
+}
+
+func (r *recordReader) nextWithKey(key RecordKeyFunc) (map[string]interface{}, []interface{}, error) {
+	record, err := r.next()
+	if err != nil {
+		return nil, nil, err
+	}
+	recordKey, err := key(record)
+	if err != nil {
+		return nil, nil, r.errorf("%s", err)
+	}
+	return record, recordKey, nil
+}
+
+// nextSorted returns the next record and verifies that its key is greater
+// than the previous key.
+func (r *recordReader) nextSorted(key RecordKeyFunc, previous []interface{}) (map[string]interface{}, []interface{}, error) {
+	record, recordKey, err := r.nextWithKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if previous != nil && compareRecordKeys(previous, recordKey) >= 0 {
+		return nil, nil, r.errorf("records are not sorted by key: %s after %s",
+			recordKeyString(recordKey), recordKeyString(previous))
+	}
+	return record, recordKey, nil
+}
+
+func (r *recordReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", r.line, fmt.Sprintf(format, args...))
+}
+
+func recordKeyString(key []interface{}) string {
+	values := make([]string, len(key))
+	for i, value := range key {
+		bytes, _ := json.Marshal(value)
+		values[i] = string(bytes)
+	}
+	return strings.Join(values, ",")
+}
+
+func compareRecordKeys(left, right []interface{}) int {
+	for i := 0; i < len(left) && i < len(right); i++ {
+		if c := compareKeyValues(left[i], right[i]); c != 0 {
+			return c
+		}
+	}
+	return len(left) - len(right)
+}
+
+func compareKeyValues(left, right interface{}) int {
+	leftNumber, leftIsNumber := left.(float64)
+	rightNumber, rightIsNumber := right.(float64)
+	if leftIsNumber && rightIsNumber {
+		switch {
+		case leftNumber < rightNumber:
+			return -1
+		case leftNumber > rightNumber:
+			return 1
+		}
+		return 0
+	}
+	leftString, leftIsString := left.(string)
+	rightString, rightIsString := right.(string)
+	if leftIsString && rightIsString {
+		return strings.Compare(leftString, rightString)
+	}
+	return strings.Compare(recordKeyString([]interface{}{left}), recordKeyString([]interface{}{right}))
+}
@@ -0,0 +1,124 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"os"
+	"strings"
+)
+
+var _ = Describe("NDJSON", func() {
+	compare := func(left, right string, config RecordsConfig) ([]RecordDiff, error) {
+		leftFile, err := os.Open(left)
+		Expect(err).To(BeNil())
+		defer leftFile.Close()
+		rightFile, err := os.Open(right)
+		Expect(err).To(BeNil())
+		defer rightFile.Close()
+
+		records := []RecordDiff{}
+		err = New().CompareRecords(leftFile, rightFile, config, func(record RecordDiff) error {
+			records = append(records, record)
+			return nil
+		})
+		return records, err
+	}
+
+	summary := func(records []RecordDiff) (result []string) {
+		for _, record := range records {
+			result = append(result, strings.Join([]string{
+				string(record.Change), record.KeyString(),
+			}, " "))
+		}
+		return
+	}
+
+	It("Matches records by key", func() {
+		records, err := compare("FIXTURES/records.ndjson", "FIXTURES/records_changed.ndjson", RecordsConfig{
+			Key: KeyByFields("id"),
+		})
+		Expect(err).To(BeNil())
+		Expect(summary(records)).To(Equal([]string{"deleted 2", "modified 3", "added 4"}))
+
+		modified := records[1]
+		Expect(modified.LeftLine).To(Equal(4))
+		Expect(modified.RightLine).To(Equal(2))
+		Expect(modified.Diff).To(Equal(New().CompareObjects(modified.Left, modified.Right)))
+		Expect(records[2].Right["name"]).To(Equal("dave"))
+	})
+
+	It("Matches sorted records by key without loading them", func() {
+		records, err := compare("FIXTURES/records.ndjson", "FIXTURES/records_changed.ndjson", RecordsConfig{
+			Key:    KeyByFields("id"),
+			Sorted: true,
+		})
+		Expect(err).To(BeNil())
+		Expect(summary(records)).To(Equal([]string{"deleted 2", "modified 3", "added 4"}))
+	})
+
+	It("Rejects unsorted records in the sorted mode", func() {
+		differ := New()
+		err := differ.CompareRecords(
+			strings.NewReader("{\"id\": 2}\n{\"id\": 1}\n"),
+			strings.NewReader("{\"id\": 1}\n"),
+			RecordsConfig{Key: KeyByFields("id"), Sorted: true},
+			func(RecordDiff) error { return nil },
+		)
+		Expect(err).To(MatchError(ContainSubstring("line 2: records are not sorted")))
+	})
+
+	It("Matches records by line", func() {
+		records, err := compare("FIXTURES/records.ndjson", "FIXTURES/records_changed.ndjson", RecordsConfig{})
+		Expect(err).To(BeNil())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].Change).To(Equal(RecordModified))
+		Expect(records[0].LeftLine).To(Equal(2))
+		Expect(records[0].RightLine).To(Equal(2))
+		Expect(records[1].Change).To(Equal(RecordModified))
+		Expect(records[1].LeftLine).To(Equal(4))
+		Expect(records[1].RightLine).To(Equal(3))
+	})
+
+	It("Supports nested and composite keys", func() {
+		key := KeyByFields("user.id", "kind")
+		value, err := key(map[string]interface{}{
+			"user": map[string]interface{}{"id": float64(7)},
+			"kind": "a",
+		})
+		Expect(err).To(BeNil())
+		Expect(value).To(Equal([]interface{}{float64(7), "a"}))
+
+		_, err = key(map[string]interface{}{"user": "7"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Reports invalid records and duplicate keys", func() {
+		differ := New()
+		noop := func(RecordDiff) error { return nil }
+		err := differ.CompareRecords(strings.NewReader("[1]\n"), strings.NewReader(""), RecordsConfig{}, noop)
+		Expect(err).To(MatchError("line 1: record is not a JSON object"))
+
+		err = differ.CompareRecords(
+			strings.NewReader(""),
+			strings.NewReader("{\"id\": 1}\n{\"id\": 1}\n"),
+			RecordsConfig{Key: KeyByFields("id")},
+			noop,
+		)
+		Expect(err).To(MatchError("line 2: duplicate key 1"))
+	})
+
+	It("Stops when the callback returns an error", func() {
+		stop := errors.New("stop")
+		err := New().CompareRecords(
+			strings.NewReader("{\"a\": 1}\n{\"a\": 2}\n"),
+			strings.NewReader(""),
+			RecordsConfig{},
+			func(RecordDiff) error { return stop },
+		)
+		Expect(err).To(Equal(stop))
+	})
+})
@@ -0,0 +1,129 @@
+package gojsondiff
+
+// An Option configures a Differ.
+type Option func(*Differ)
+
+// An ArrayStrategy is the way to align elements of arrays.
+type ArrayStrategy int
+
+const (
+	// ArrayLCS aligns elements by their longest common subsequence, and
+	// detects moved and modified elements. This is the default.
+	ArrayLCS ArrayStrategy = iota
+	// ArrayByIndex compares elements at the same indexes.
+	ArrayByIndex
+	// ArrayUnordered compares arrays as multisets regardless of the order.
+	ArrayUnordered
+)
+
+// A CompareHook is consulted before two values at the path are compared.
+// It returns handled false to fall back to the default comparison. Otherwise,
+// the returned Delta is reported, or the values are considered the same when
+// it is nil. The Delta must have the last position of the path. Deltas of
+// values redacted by `diff:"redact"` tags are replaced with redacted ones.
+type CompareHook func(path []Position, left, right interface{}) (delta Delta, handled bool)
+
+// With returns a copy of the Differ modified by the options.
+func (differ *Differ) With(opts ...Option) *Differ {
+	derived := *differ
+	for _, opt := range opts {
+		opt(&derived)
+	}
+	return &derived
+}
+
+// WithTextDiffMinimumLength sets the minimum length of strings compared as
+// texts. Shorter strings are reported as Modified instead of TextDiff.
+// A negative length disables text diffs. The default is 30.
+func WithTextDiffMinimumLength(length int) Option {
+	return func(differ *Differ) {
+		differ.textDiffMinimumLength = length
+	}
+}
+
+// WithMoveDetection enables or disables detecting moved elements of arrays.
+// Without it, moved elements are reported as deleted and added.
+// It is enabled by default.
+func WithMoveDetection(enabled bool) Option {
+	return func(differ *Differ) {
+		differ.ignoreMoves = !enabled
+	}
+}
+
+// WithSimilarityThreshold sets the minimum similarity, from 0 to 1, of array
+// elements reported as modified. Less similar elements are reported as
+// deleted and added. The default is 0.
+func WithSimilarityThreshold(threshold float64) Option {
+	return func(differ *Differ) {
+		differ.similarityThreshold = threshold
+	}
+}
+
+// WithMaxDepth limits the depth of Deltas. Objects and arrays at the depth
+// are compared as a whole and reported as Modified. The children of the
+// root are at depth 1. Zero means no limit, which is the default.
+func WithMaxDepth(depth int) Option {
+	return func(differ *Differ) {
+		differ.maxDepth = depth
+	}
+}
+
+// WithArrayStrategy sets the way to align elements of arrays.
+// Struct tags of CompareValues take precedence over the strategy.
+func WithArrayStrategy(strategy ArrayStrategy) Option {
+	return func(differ *Differ) {
+		differ.arrayStrategy = strategy
+	}
+}
+
+// WithCompareHook adds a hook consulted before comparing values. Hooks are
+// consulted in the order they are added.
+func WithCompareHook(hook CompareHook) Option {
+	return func(differ *Differ) {
+		differ.hooks = append(differ.hooks[:len(differ.hooks):len(differ.hooks)], hook)
+	}
+}
+
+// WithLenient sets Differ.Lenient.
+func WithLenient(enabled bool) Option {
+	return func(differ *Differ) {
+		differ.Lenient = enabled
+	}
+}
+
+// WithPreserveKeyOrder sets Differ.PreserveKeyOrder.
+func WithPreserveKeyOrder(enabled bool) Option {
+	return func(differ *Differ) {
+		differ.PreserveKeyOrder = enabled
+	}
+}
+
+// WithRecordPositions sets Differ.RecordPositions.
+func WithRecordPositions(enabled bool) Option {
+	return func(differ *Differ) {
+		differ.RecordPositions = enabled
+	}
+}
+
+// compareArraysByIndex compares elements at the same indexes. The remaining
+// elements of the longer array are reported as deleted or added.
+func (differ *Differ) compareArraysByIndex(
+	path []Position,
+	left []interface{},
+	right []interface{},
+	elem *valuePolicy,
+) (deltas []Delta) {
+	deltas = make([]Delta, 0)
+	for i := 0; i < len(left) && i < len(right); i++ {
+		if same, delta := differ.compareValues(path, Index(i), left[i], right[i], elem); !same {
+			deltas = append(deltas, delta)
+		}
+	}
+	for i := len(right); i < len(left); i++ {
+		deltas = append(deltas, NewDeleted(Index(i), elem.hide(left[i])))
+	}
+	for i := len(left); i < len(right); i++ {
+		deltas = append(deltas, NewAdded(Index(i), elem.hide(right[i])))
+	}
+	return deltas
+}
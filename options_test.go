@@ -0,0 +1,111 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"strings"
+)
+
+var _ = Describe("Options", func() {
+	compare := func(differ *Differ, left, right map[string]interface{}) []Delta {
+		return differ.CompareObjects(left, right).Deltas()
+	}
+
+	It("sets the minimum length of text diffs", func() {
+		left := map[string]interface{}{"a": "short text"}
+		right := map[string]interface{}{"a": "short test"}
+
+		_, ok := compare(New(), left, right)[0].(*TextDiff)
+		Expect(ok).To(BeFalse())
+		_, ok = compare(New(WithTextDiffMinimumLength(5)), left, right)[0].(*TextDiff)
+		Expect(ok).To(BeTrue())
+
+		long := strings.Repeat("text ", 10)
+		_, ok = compare(New(WithTextDiffMinimumLength(-1)), map[string]interface{}{"a": long}, map[string]interface{}{"a": long + "!"})[0].(*TextDiff)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("disables move detection", func() {
+		left := map[string]interface{}{"a": []interface{}{1.0, 2.0, 3.0}}
+		right := map[string]interface{}{"a": []interface{}{2.0, 3.0, 1.0}}
+
+		deltas := compare(New(), left, right)[0].(*Array).Deltas
+		Expect(deltas).To(HaveLen(1))
+		Expect(deltas[0]).To(BeAssignableToTypeOf(&Moved{}))
+
+		deltas = compare(New(WithMoveDetection(false)), left, right)[0].(*Array).Deltas
+		Expect(deltas).To(Equal([]Delta{NewDeleted(Index(0), 1.0), NewAdded(Index(2), 1.0)}))
+	})
+
+	It("reports dissimilar elements as deleted and added", func() {
+		left := map[string]interface{}{"a": []interface{}{"x", map[string]interface{}{"k": 1.0, "l": 2.0}}}
+		right := map[string]interface{}{"a": []interface{}{"x", map[string]interface{}{"k": 3.0, "l": 4.0}}}
+
+		deltas := compare(New(), left, right)[0].(*Array).Deltas
+		Expect(deltas).To(HaveLen(1))
+		Expect(deltas[0]).To(BeAssignableToTypeOf(&Object{}))
+
+		deltas = compare(New(WithSimilarityThreshold(0.9)), left, right)[0].(*Array).Deltas
+		Expect(deltas).To(HaveLen(2))
+		Expect(deltas[0]).To(BeAssignableToTypeOf(&Deleted{}))
+		Expect(deltas[1]).To(BeAssignableToTypeOf(&Added{}))
+	})
+
+	It("limits the depth", func() {
+		left := map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1.0}}}
+		right := map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 2.0}}}
+
+		deltas := compare(New(WithMaxDepth(2)), left, right)
+		Expect(deltas).To(HaveLen(1))
+		inner := deltas[0].(*Object).Deltas
+		Expect(inner).To(Equal([]Delta{NewModified(Name("b"), map[string]interface{}{"c": 1.0}, map[string]interface{}{"c": 2.0})}))
+	})
+
+	It("aligns arrays with strategies", func() {
+		left := map[string]interface{}{"a": []interface{}{1.0, 2.0, 3.0}}
+		right := map[string]interface{}{"a": []interface{}{3.0, 2.0, 1.0, 4.0}}
+
+		deltas := compare(New(WithArrayStrategy(ArrayByIndex)), left, right)[0].(*Array).Deltas
+		Expect(deltas).To(Equal([]Delta{
+			NewModified(Index(0), 1.0, 3.0),
+			NewModified(Index(2), 3.0, 1.0),
+			NewAdded(Index(3), 4.0),
+		}))
+
+		deltas = compare(New(WithArrayStrategy(ArrayUnordered)), left, right)[0].(*Array).Deltas
+		Expect(deltas).To(Equal([]Delta{NewAdded(Index(3), 4.0)}))
+	})
+
+	It("consults hooks", func() {
+		paths := []string{}
+		hook := func(path []Position, left, right interface{}) (Delta, bool) {
+			paths = append(paths, JSONPointer(path))
+			if path[len(path)-1] == Name("ignored") {
+				return nil, true
+			}
+			return nil, false
+		}
+		left := map[string]interface{}{"ignored": 1.0, "b": map[string]interface{}{"c": 1.0}}
+		right := map[string]interface{}{"ignored": 2.0, "b": map[string]interface{}{"c": 2.0}}
+
+		deltas := compare(New(WithCompareHook(hook)), left, right)
+		Expect(deltas).To(HaveLen(1))
+		Expect(deltas[0].(*Object).Position).To(Equal(Name("b")))
+		Expect(paths).To(Equal([]string{"/b", "/b/c", "/ignored"}))
+	})
+
+	It("derives differs", func() {
+		base := New(WithMaxDepth(1))
+		derived := base.With(WithMaxDepth(0), WithLenient(true))
+		Expect(derived.Lenient).To(BeTrue())
+		Expect(base.Lenient).To(BeFalse())
+
+		left := map[string]interface{}{"a": map[string]interface{}{"b": 1.0}}
+		right := map[string]interface{}{"a": map[string]interface{}{"b": 2.0}}
+		Expect(compare(base, left, right)[0]).To(BeAssignableToTypeOf(&Modified{}))
+		Expect(compare(derived, left, right)[0]).To(BeAssignableToTypeOf(&Object{}))
+	})
+})
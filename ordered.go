@@ -0,0 +1,216 @@
+package gojsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// An OrderedMap is a JSON object that keeps the order of its keys.
+// Differ compares OrderedMaps in the same way as map[string]interface{} and
+// reports their Deltas in the order of the keys. Patches keep the order of
+// existing keys and append added keys.
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewOrderedMap returns an empty OrderedMap.
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{
+		keys:   []string{},
+		values: map[string]interface{}{},
+	}
+}
+
+// UnmarshalOrdered parses a JSON document and returns it in the data model
+// of encoding/json, except that objects are decoded into *OrderedMap.
+func UnmarshalOrdered(data []byte) (interface{}, error) {
+	p := &parser{data: data, ordered: true}
+	return p.parse()
+}
+
+// Keys returns the keys in order. The returned slice must not be modified.
+func (m *OrderedMap) Keys() []string {
+	return m.keys
+}
+
+// Values returns the values by keys. The returned map must not be modified.
+func (m *OrderedMap) Values() map[string]interface{} {
+	return m.values
+}
+
+// Len returns the number of keys.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// Get returns the value of the key.
+func (m *OrderedMap) Get(key string) (value interface{}, ok bool) {
+	value, ok = m.values[key]
+	return
+}
+
+// Set sets the value of the key. New keys are appended to the end.
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if m.values == nil {
+		m.values = map[string]interface{}{}
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Delete removes the key.
+func (m *OrderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// placeRenamedKeys moves the keys renamed by the deltas to the positions of
+// their old keys in keys, the keys before the deltas were applied.
+func (m *OrderedMap) placeRenamedKeys(keys []string, deltas []Delta) {
+	renamed := map[string]string{}
+	isNew := map[string]bool{}
+	for _, delta := range deltas {
+		moved, ok := delta.(*Moved)
+		if !ok {
+			continue
+		}
+		if name, ok := moved.PostPosition().(Name); ok {
+			renamed[string(moved.PrePosition().(Name))] = string(name)
+			isNew[string(name)] = true
+		}
+	}
+	if len(renamed) == 0 {
+		return
+	}
+
+	placed := make([]string, 0, len(m.keys))
+	seen := make(map[string]bool, len(m.keys))
+	for _, key := range keys {
+		if name, ok := renamed[key]; ok {
+			key = name
+		} else if isNew[key] {
+			// the key is placed at the position of its old key
+			continue
+		}
+		if _, ok := m.values[key]; ok && !seen[key] {
+			placed = append(placed, key)
+			seen[key] = true
+		}
+	}
+	for _, key := range m.keys {
+		if !seen[key] {
+			placed = append(placed, key)
+		}
+	}
+	m.keys = placed
+}
+
+// MarshalJSON encodes the object with its keys in order. HTML characters
+// are left to the caller to escape, as json.Marshal does.
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBuffer([]byte{})
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	buffer.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		if err := encoder.Encode(key); err != nil {
+			return nil, err
+		}
+		buffer.Truncate(buffer.Len() - 1)
+		buffer.WriteByte(':')
+		if err := encoder.Encode(m.values[key]); err != nil {
+			return nil, err
+		}
+		buffer.Truncate(buffer.Len() - 1)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON object keeping the order of its keys.
+// Nested objects are decoded into *OrderedMap as well.
+func (m *OrderedMap) UnmarshalJSON(data []byte) error {
+	value, err := UnmarshalOrdered(data)
+	if err != nil {
+		return err
+	}
+	object, ok := value.(*OrderedMap)
+	if !ok {
+		return errors.New("not a JSON object")
+	}
+	*m = *object
+	return nil
+}
+
+// plainValue converts OrderedMaps in the value into map[string]interface{}
+// so that values are compared regardless of their key order.
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *OrderedMap:
+		m := make(map[string]interface{}, len(v.values))
+		for key, item := range v.values {
+			m[key] = plainValue(item)
+		}
+		return m
+	case map[string]interface{}:
+		if !containsOrderedMap(v) {
+			return v
+		}
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = plainValue(item)
+		}
+		return m
+	case []interface{}:
+		return plainValues(v)
+	}
+	return value
+}
+
+// plainValues applies plainValue to the values. The values are returned as
+// they are when they contain no OrderedMap.
+func plainValues(values []interface{}) []interface{} {
+	if !containsOrderedMap(values) {
+		return values
+	}
+	plain := make([]interface{}, len(values))
+	for i, value := range values {
+		plain[i] = plainValue(value)
+	}
+	return plain
+}
+
+func containsOrderedMap(value interface{}) bool {
+	switch v := value.(type) {
+	case *OrderedMap:
+		return true
+	case map[string]interface{}:
+		for _, item := range v {
+			if containsOrderedMap(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if containsOrderedMap(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
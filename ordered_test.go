@@ -0,0 +1,111 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"encoding/json"
+)
+
+var _ = Describe("OrderedMap", func() {
+	It("Keeps the order of keys", func() {
+		value, err := UnmarshalOrdered([]byte(`{"b": 1, "a": {"d": 2, "c": 3}, "c": [{"z": 1, "y": 2}]}`))
+		Expect(err).To(BeNil())
+		m := value.(*OrderedMap)
+		Expect(m.Keys()).To(Equal([]string{"b", "a", "c"}))
+		nested, _ := m.Get("a")
+		Expect(nested.(*OrderedMap).Keys()).To(Equal([]string{"d", "c"}))
+
+		bytes, err := json.Marshal(m)
+		Expect(err).To(BeNil())
+		Expect(string(bytes)).To(Equal(`{"b":1,"a":{"d":2,"c":3},"c":[{"z":1,"y":2}]}`))
+	})
+
+	It("Appends new keys and removes deleted keys", func() {
+		m := NewOrderedMap()
+		Expect(json.Unmarshal([]byte(`{"b": 1, "a": 2, "c": 3}`), m)).To(Succeed())
+		m.Set("a", 4)
+		m.Set("d", 5)
+		m.Delete("b")
+		Expect(m.Keys()).To(Equal([]string{"a", "c", "d"}))
+		Expect(m.Len()).To(Equal(3))
+		value, ok := m.Get("a")
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal(4))
+	})
+
+	It("Sets keys of zero OrderedMaps", func() {
+		var m OrderedMap
+		m.Set("b", 1)
+		m.Set("a", 2)
+		Expect(m.Keys()).To(Equal([]string{"b", "a"}))
+		Expect(json.Marshal(&m)).To(MatchJSON(`{"b": 1, "a": 2}`))
+	})
+
+	It("Encodes keys in order in YAML", func() {
+		value, err := UnmarshalOrdered([]byte(`{"b": 1, "a": {"d": 2, "c": 3}}`))
+		Expect(err).To(BeNil())
+		yaml, err := MarshalYAML(value)
+		Expect(err).To(BeNil())
+		Expect(string(yaml)).To(Equal("b: 1\na:\n  d: 2\n  c: 3\n"))
+	})
+
+	Describe("Differ", func() {
+		left := []byte(`{"version": 1, "deps": {"zeta": "1", "alpha": "2"}, "name": "app", "list": [{"b": 1, "a": 2}, 3]}`)
+		right := []byte(`{"name": "app", "version": 2, "deps": {"zeta": "2", "gamma": "1", "alpha": "2"}, "list": [3, {"a": 2, "b": 1}]}`)
+
+		It("Reports deltas in the order of keys", func() {
+			differ := New()
+			differ.PreserveKeyOrder = true
+			diff, err := differ.Compare(left, right)
+			Expect(err).To(BeNil())
+
+			deltas := diff.Deltas()
+			Expect(deltas).To(HaveLen(3))
+			Expect(deltas[0].(*Modified).Position).To(Equal(Name("version")))
+			Expect(deltas[1].(*Object).Position).To(Equal(Name("deps")))
+			Expect(deltas[1].(*Object).Deltas[0].(*Modified).Position).To(Equal(Name("zeta")))
+			Expect(deltas[1].(*Object).Deltas[1].(*Added).Position).To(Equal(Name("gamma")))
+
+			// objects with different key orders are the same
+			list := deltas[2].(*Array)
+			Expect(list.Deltas).To(HaveLen(1))
+			Expect(list.Deltas[0]).To(BeAssignableToTypeOf(&Moved{}))
+		})
+
+		It("Applies patches keeping the order of keys", func() {
+			differ := New()
+			differ.PreserveKeyOrder = true
+			diff, err := differ.Compare(left, right)
+			Expect(err).To(BeNil())
+
+			value, err := differ.Unmarshal(left)
+			Expect(err).To(BeNil())
+			m := value.(*OrderedMap)
+			differ.ApplyPatchToOrderedMap(m, diff)
+
+			bytes, err := json.Marshal(m)
+			Expect(err).To(BeNil())
+			Expect(string(bytes)).To(Equal(
+				`{"version":2,"deps":{"zeta":"2","alpha":"2","gamma":"1"},"name":"app","list":[3,{"b":1,"a":2}]}`,
+			))
+		})
+
+		It("Keeps renamed keys at their positions", func() {
+			differ := New(WithRenameDetection(1))
+			differ.PreserveKeyOrder = true
+			before := []byte(`{"a": 1, "b": {"x": 1, "y": 2}, "c": 3, "d": "text"}`)
+			after := []byte(`{"a": 1, "bb": {"x": 1, "y": 2}, "c": 3, "dd": "text", "e": 5}`)
+			diff, err := differ.Compare(before, after)
+			Expect(err).To(BeNil())
+
+			value, err := differ.Unmarshal(before)
+			Expect(err).To(BeNil())
+			m := value.(*OrderedMap)
+			differ.ApplyPatchToOrderedMap(m, diff)
+			Expect(m.Keys()).To(Equal([]string{"a", "bb", "c", "dd", "e"}))
+		})
+	})
+})
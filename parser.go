@@ -0,0 +1,462 @@
+package gojsondiff
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// UnmarshalJSON5 parses a JSON document written in the JSON5 or JSONC
+// syntax and returns it in the data model of encoding/json.
+// In addition to JSON, it accepts comments, trailing commas, unquoted keys,
+// single quoted strings, hexadecimal numbers and numbers with leading plus
+// signs or leading and trailing decimal points. Comments are not preserved.
+func UnmarshalJSON5(data []byte) (interface{}, error) {
+	p := &parser{data: data, lenient: true}
+	return p.parse()
+}
+
+// A ParseError describes a syntax error found in a JSON document.
+type ParseError struct {
+	Message string
+	// Offset is the byte offset in the input where the error occurred
+	Offset int
+	// Line and Column are 1-based position where the error occurred
+	Line   int
+	Column int
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at line %d, column %d", e.Message, e.Line, e.Column)
+}
+
+// A parser is a recursive descent parser for JSON documents.
+// With lenient, it also accepts the extensions of JSON5.
+// With ordered, it decodes objects into *OrderedMap.
+// With sourceMap, it records the positions of values.
+type parser struct {
+	data    []byte
+	offset  int
+	lenient bool
+	ordered bool
+
+	sourceMap SourceMap
+	pointer   []string
+	cursor    *sourceCursor
+}
+
+var (
+	strictNumberPattern  = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+	lenientNumberPattern = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+	hexNumberPattern     = regexp.MustCompile(`^[+-]?0[xX][0-9a-fA-F]+$`)
+)
+
+func (p *parser) parse() (interface{}, error) {
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+	if p.offset < len(p.data) {
+		return nil, p.errorf("unexpected character %q after the top-level value", p.data[p.offset])
+	}
+	return value, nil
+}
+
+func (p *parser) value() (interface{}, error) {
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+	if p.offset >= len(p.data) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	p.record(p.offset)
+
+	switch c := p.data[p.offset]; {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"' || (c == '\'' && p.lenient):
+		return p.string()
+	case c == '-' || c == '+' || c == '.' || ('0' <= c && c <= '9'):
+		return p.number()
+	case isIdentifierStart(rune(c)):
+		return p.literal()
+	default:
+		return nil, p.errorf("unexpected character %q", c)
+	}
+}
+
+func (p *parser) object() (interface{}, error) {
+	keys, values, err := p.members()
+	if err != nil {
+		return nil, err
+	}
+	if p.ordered {
+		return &OrderedMap{keys: keys, values: values}, nil
+	}
+	return values, nil
+}
+
+// members parses the members of an object and returns its keys in order.
+func (p *parser) members() (keys []string, values map[string]interface{}, err error) {
+	p.offset++ // {
+	keys = []string{}
+	values = map[string]interface{}{}
+	for {
+		if err := p.skip(); err != nil {
+			return nil, nil, err
+		}
+		if p.consume('}') {
+			return keys, values, nil
+		}
+
+		start := p.offset
+		key, err := p.key()
+		if err != nil {
+			return nil, nil, err
+		}
+		p.pointer = append(p.pointer, key)
+		p.record(start)
+		if err := p.skip(); err != nil {
+			return nil, nil, err
+		}
+		if !p.consume(':') {
+			return nil, nil, p.errorf("expected ':' after object key")
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, nil, err
+		}
+		p.pointer = p.pointer[:len(p.pointer)-1]
+		if _, dup := values[key]; !dup {
+			keys = append(keys, key)
+		}
+		values[key] = value
+
+		if err := p.skip(); err != nil {
+			return nil, nil, err
+		}
+		if p.consume('}') {
+			return keys, values, nil
+		}
+		if !p.consume(',') {
+			return nil, nil, p.errorf("expected ',' or '}' in object")
+		}
+		if !p.lenient {
+			if err := p.skip(); err != nil {
+				return nil, nil, err
+			}
+			if p.offset < len(p.data) && p.data[p.offset] == '}' {
+				return nil, nil, p.errorf("trailing comma in object")
+			}
+		}
+	}
+}
+
+func (p *parser) key() (string, error) {
+	if p.offset >= len(p.data) {
+		return "", p.errorf("unexpected end of input")
+	}
+	c := p.data[p.offset]
+	if c == '"' || (c == '\'' && p.lenient) {
+		return p.string()
+	}
+	if p.lenient && isIdentifierStart(rune(c)) {
+		return p.identifier(), nil
+	}
+	return "", p.errorf("expected object key")
+}
+
+func (p *parser) array() (interface{}, error) {
+	p.offset++ // [
+	array := []interface{}{}
+	for {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.consume(']') {
+			return array, nil
+		}
+
+		p.pointer = append(p.pointer, strconv.Itoa(len(array)))
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		p.pointer = p.pointer[:len(p.pointer)-1]
+		array = append(array, value)
+
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.consume(']') {
+			return array, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+		if !p.lenient {
+			if err := p.skip(); err != nil {
+				return nil, err
+			}
+			if p.offset < len(p.data) && p.data[p.offset] == ']' {
+				return nil, p.errorf("trailing comma in array")
+			}
+		}
+	}
+}
+
+func (p *parser) string() (string, error) {
+	quote := p.data[p.offset]
+	p.offset++
+	buffer := bytes.NewBuffer([]byte{})
+	for {
+		if p.offset >= len(p.data) {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.data[p.offset]
+		switch {
+		case c == quote:
+			p.offset++
+			return buffer.String(), nil
+		case c == '\\':
+			if err := p.escape(buffer); err != nil {
+				return "", err
+			}
+		case c < 0x20:
+			return "", p.errorf("invalid character %q in string", c)
+		default:
+			r, size := utf8.DecodeRune(p.data[p.offset:])
+			buffer.WriteRune(r)
+			p.offset += size
+		}
+	}
+}
+
+func (p *parser) escape(buffer *bytes.Buffer) error {
+	p.offset++ // backslash
+	if p.offset >= len(p.data) {
+		return p.errorf("unterminated string")
+	}
+	c := p.data[p.offset]
+	p.offset++
+	switch c {
+	case '"', '\\', '/':
+		buffer.WriteByte(c)
+	case 'b':
+		buffer.WriteByte('\b')
+	case 'f':
+		buffer.WriteByte('\f')
+	case 'n':
+		buffer.WriteByte('\n')
+	case 'r':
+		buffer.WriteByte('\r')
+	case 't':
+		buffer.WriteByte('\t')
+	case 'u':
+		r, err := p.hex(4)
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) && p.offset+1 < len(p.data) &&
+			p.data[p.offset] == '\\' && p.data[p.offset+1] == 'u' {
+			p.offset += 2
+			r2, err := p.hex(4)
+			if err != nil {
+				return err
+			}
+			r = utf16.DecodeRune(r, r2)
+		}
+		buffer.WriteRune(r)
+	default:
+		if !p.lenient {
+			return p.errorf("invalid escape sequence '\\%c'", c)
+		}
+		switch c {
+		case '\'':
+			buffer.WriteByte('\'')
+		case 'v':
+			buffer.WriteByte('\v')
+		case '0':
+			buffer.WriteByte(0)
+		case 'x':
+			r, err := p.hex(2)
+			if err != nil {
+				return err
+			}
+			buffer.WriteRune(r)
+		case '\n':
+			// line continuation
+		case '\r':
+			p.consume('\n')
+		default:
+			buffer.WriteByte(c)
+		}
+	}
+	return nil
+}
+
+func (p *parser) hex(digits int) (rune, error) {
+	if p.offset+digits > len(p.data) {
+		return 0, p.errorf("unterminated escape sequence")
+	}
+	value, err := strconv.ParseUint(string(p.data[p.offset:p.offset+digits]), 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	p.offset += digits
+	return rune(value), nil
+}
+
+func (p *parser) number() (interface{}, error) {
+	start := p.offset
+	for p.offset < len(p.data) && isNumberCharacter(p.data[p.offset]) {
+		p.offset++
+	}
+	text := string(p.data[start:p.offset])
+
+	if p.lenient && hexNumberPattern.MatchString(text) {
+		value, err := strconv.ParseInt(text, 0, 64)
+		if err != nil {
+			return nil, p.errorAt(start, "invalid number %q", text)
+		}
+		return float64(value), nil
+	}
+
+	pattern := strictNumberPattern
+	if p.lenient {
+		pattern = lenientNumberPattern
+	}
+	if !pattern.MatchString(text) {
+		return nil, p.errorAt(start, "invalid number %q", text)
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, p.errorAt(start, "invalid number %q", text)
+	}
+	return value, nil
+}
+
+func (p *parser) literal() (interface{}, error) {
+	start := p.offset
+	switch word := p.identifier(); word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	default:
+		return nil, p.errorAt(start, "unexpected literal %q", word)
+	}
+}
+
+func (p *parser) identifier() string {
+	start := p.offset
+	for p.offset < len(p.data) {
+		r, size := utf8.DecodeRune(p.data[p.offset:])
+		if !isIdentifierStart(r) && !('0' <= r && r <= '9') {
+			break
+		}
+		p.offset += size
+	}
+	return string(p.data[start:p.offset])
+}
+
+// skip skips whitespaces and, in the lenient mode, comments.
+func (p *parser) skip() error {
+	for p.offset < len(p.data) {
+		switch p.data[p.offset] {
+		case ' ', '\t', '\n', '\r':
+			p.offset++
+		case '/':
+			if !p.lenient || p.offset+1 >= len(p.data) {
+				return p.errorf("unexpected character '/'")
+			}
+			switch p.data[p.offset+1] {
+			case '/':
+				end := bytes.IndexByte(p.data[p.offset:], '\n')
+				if end < 0 {
+					p.offset = len(p.data)
+				} else {
+					p.offset += end + 1
+				}
+			case '*':
+				end := bytes.Index(p.data[p.offset+2:], []byte("*/"))
+				if end < 0 {
+					return p.errorf("unterminated comment")
+				}
+				p.offset += 2 + end + 2
+			default:
+				return p.errorf("unexpected character '/'")
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// record records the offset as the position of the current value.
+// The first position is kept for the value.
+func (p *parser) record(offset int) {
+	if p.sourceMap == nil {
+		return
+	}
+	pointer := joinPointer(p.pointer)
+	if _, ok := p.sourceMap[pointer]; !ok {
+		if p.cursor == nil {
+			p.cursor = newSourceCursor(p.data)
+		}
+		p.sourceMap[pointer] = p.cursor.at(offset)
+	}
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.offset < len(p.data) && p.data[p.offset] == c {
+		p.offset++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.offset, format, args...)
+}
+
+func (p *parser) errorAt(offset int, format string, args ...interface{}) error {
+	line, column := lineAndColumn(p.data, offset)
+	return &ParseError{
+		Message: fmt.Sprintf(format, args...),
+		Offset:  offset,
+		Line:    line,
+		Column:  column,
+	}
+}
+
+// lineAndColumn returns the 1-based line and column (in runes) of the offset.
+func lineAndColumn(data []byte, offset int) (line, column int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	line = bytes.Count(data[:offset], []byte{'\n'}) + 1
+	column = utf8.RuneCount(data[lineStart:offset]) + 1
+	return
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '_' || r == '$' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || r >= utf8.RuneSelf
+}
+
+func isNumberCharacter(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F') ||
+		c == '.' || c == '-' || c == '+' || c == 'x' || c == 'X'
+}
@@ -0,0 +1,84 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/yudai/gojsondiff/tests"
+
+	"io/ioutil"
+)
+
+var _ = Describe("Parser", func() {
+	Describe("UnmarshalJSON5", func() {
+		It("Accepts plain JSON", func() {
+			value, err := UnmarshalJSON5([]byte(`{"a": [1, -2.5e3, "xé\n"], "b": {"c": null, "d": false}}`))
+			Expect(err).To(BeNil())
+			Expect(value).To(Equal(map[string]interface{}{
+				"a": []interface{}{float64(1), float64(-2500), "xé\n"},
+				"b": map[string]interface{}{"c": nil, "d": false},
+			}))
+		})
+
+		It("Accepts comments, trailing commas and unquoted keys", func() {
+			value, err := UnmarshalJSON5([]byte(`
+				// line comment
+				{
+					/* block
+					   comment */
+					key: 'single \'quoted\'',
+					$other_key: [0x1F, +1, .5, 2.,],
+				}
+			`))
+			Expect(err).To(BeNil())
+			Expect(value).To(Equal(map[string]interface{}{
+				"key":        "single 'quoted'",
+				"$other_key": []interface{}{float64(31), float64(1), float64(0.5), float64(2)},
+			}))
+		})
+
+		It("Reports the position of syntax errors", func() {
+			_, err := UnmarshalJSON5([]byte("{\n  \"a\": 1,\n  \"b\" 2\n}"))
+			Expect(err).To(HaveOccurred())
+			parseError, ok := err.(*ParseError)
+			Expect(ok).To(BeTrue())
+			Expect(parseError.Line).To(Equal(3))
+			Expect(parseError.Column).To(Equal(7))
+			Expect(err.Error()).To(Equal("expected ':' after object key at line 3, column 7"))
+		})
+
+		It("Rejects unterminated comments", func() {
+			_, err := UnmarshalJSON5([]byte(`{"a": 1} /* comment`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Differ", func() {
+		It("Compares JSONC and JSON5 documents in the lenient mode", func() {
+			a, err := ioutil.ReadFile("FIXTURES/base.jsonc")
+			Expect(err).To(BeNil())
+			b, err := ioutil.ReadFile("FIXTURES/base_changed.json5")
+			Expect(err).To(BeNil())
+
+			differ := New()
+			_, err = differ.Compare(a, b)
+			Expect(err).To(HaveOccurred())
+
+			differ.Lenient = true
+			diff, err := differ.Compare(a, b)
+			Expect(err).To(BeNil())
+			Expect(diff).To(Equal(differ.CompareObjects(
+				LoadFixture("FIXTURES/base.json"),
+				LoadFixture("FIXTURES/base_changed.json"),
+			)))
+		})
+
+		It("Rejects documents that are not objects in the lenient mode", func() {
+			differ := New()
+			differ.Lenient = true
+			_, err := differ.Compare([]byte(`[1, 2,]`), []byte(`{}`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,569 @@
+package gojsondiff
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+var (
+	// ErrFieldNotFound is returned when a struct has no field, or a map has
+	// no value, for a key.
+	ErrFieldNotFound = errors.New("field not found")
+	// ErrIndexOutOfRange is returned when an index is out of a slice or array.
+	ErrIndexOutOfRange = errors.New("index out of range")
+	// ErrTypeMismatch is returned when a Delta does not fit the type.
+	ErrTypeMismatch = errors.New("type mismatch")
+)
+
+// A PatchError describes a Delta that cannot be applied to a Go value.
+// Err is one of ErrFieldNotFound, ErrIndexOutOfRange and ErrTypeMismatch,
+// possibly wrapped with the cause.
+type PatchError struct {
+	// Path is the position of the Delta from the root
+	Path []Position
+	// Type is the type of the value that holds the position
+	Type reflect.Type
+	Err  error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("cannot patch %q of %v: %s", JSONPointer(e.Path), e.Type, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyPatchToValue applies a Diff to a Go value in place. The target must
+// be a pointer to a struct, map, slice or other value.
+// Name positions are resolved through `json` struct tags and Index positions
+// on slices and arrays. New values are converted to the target types as
+// encoding/json decodes them. The target can be partially patched when an
+// error is returned.
+func (differ *Differ) ApplyPatchToValue(target interface{}, patch Diff) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		var t reflect.Type
+		if v.IsValid() {
+			t = v.Type()
+		}
+		return &PatchError{Path: []Position{}, Type: t, Err: fmt.Errorf("%w: target must be a non-nil pointer", ErrTypeMismatch)}
+	}
+	return applyDeltasToValue([]Position{}, patch.Deltas(), v.Elem())
+}
+
+// applyDeltasToValue applies the deltas to the settable value v in the same
+// order as applyDeltas.
+func applyDeltasToValue(path []Position, deltas []Delta, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if isMarshaled(v) {
+		return patchMarshaled(path, v, func(value interface{}) (interface{}, error) {
+			return applyDeltas(deltas, value), nil
+		})
+	}
+
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return &PatchError{Path: path, Type: v.Type(), Err: fmt.Errorf("%w: nil interface", ErrTypeMismatch)}
+		}
+		elem := v.Elem()
+		switch elem.Interface().(type) {
+		case map[string]interface{}, []interface{}:
+			// values in the data model of encoding/json
+			v.Set(reflect.ValueOf(applyDeltas(deltas, elem.Interface())))
+			return nil
+		}
+		// patch a settable copy of the dynamic value
+		copied := reflect.New(elem.Type()).Elem()
+		copied.Set(elem)
+		if err := applyDeltasToValue(path, deltas, copied); err != nil {
+			return err
+		}
+		v.Set(copied)
+		return nil
+	}
+
+	pres := make(preDeltas, 0)
+	posts := make(postDeltas, 0, len(deltas))
+	for _, delta := range deltas {
+		if pre, ok := delta.(PreDelta); ok {
+			pres = append(pres, pre)
+		}
+		if post, ok := delta.(PostDelta); ok {
+			posts = append(posts, post)
+		}
+	}
+	sort.Sort(pres)
+	sort.Sort(posts)
+
+	moved := map[*Moved]reflect.Value{}
+	for _, delta := range pres {
+		if err := applyPreDeltaToValue(path, delta, v, moved); err != nil {
+			return err
+		}
+	}
+	for _, delta := range posts {
+		if err := applyPostDeltaToValue(path, delta, v, moved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyPreDeltaToValue(path []Position, delta PreDelta, v reflect.Value, moved map[*Moved]reflect.Value) error {
+	position := delta.PrePosition()
+	switch d := delta.(type) {
+	case *Deleted:
+		switch v.Kind() {
+		case reflect.Struct:
+			field, err := structFieldOf(path, v, position)
+			if err != nil {
+				return err
+			}
+			field.value.Set(reflect.Zero(field.value.Type()))
+			return nil
+		case reflect.Map:
+			key, err := mapKeyOf(path, v, position)
+			if err != nil {
+				return err
+			}
+			if !v.IsNil() {
+				v.SetMapIndex(key, reflect.Value{})
+			}
+			return nil
+		case reflect.Slice:
+			i, err := indexOf(path, v, position, v.Len()-1)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.AppendSlice(v.Slice(0, i), v.Slice(i+1, v.Len())))
+			return nil
+		}
+	case *Moved:
+		if v.Kind() == reflect.Slice {
+			i, err := indexOf(path, v, position, v.Len()-1)
+			if err != nil {
+				return err
+			}
+			item := reflect.New(v.Type().Elem()).Elem()
+			item.Set(v.Index(i))
+			moved[d] = item
+			v.Set(reflect.AppendSlice(v.Slice(0, i), v.Slice(i+1, v.Len())))
+			return nil
+		}
+		if v.Kind() == reflect.Map {
+			key, err := mapKeyOf(path, v, position)
+			if err != nil {
+				return err
+			}
+			item := v.MapIndex(key)
+			if !item.IsValid() {
+				return &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: fmt.Errorf("%w: key is missing", ErrFieldNotFound)}
+			}
+			moved[d] = item
+			v.SetMapIndex(key, reflect.Value{})
+			return nil
+		}
+	}
+	return &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: fmt.Errorf("%w: cannot apply %T", ErrTypeMismatch, delta)}
+}
+
+func applyPostDeltaToValue(path []Position, delta PostDelta, v reflect.Value, moved map[*Moved]reflect.Value) error {
+	position := delta.PostPosition()
+	switch d := delta.(type) {
+	case *Object:
+		return patchElement(path, v, position, func(elem reflect.Value) error {
+			return applyDeltasToValue(childPosition(path, position), d.Deltas, elem)
+		})
+	case *Array:
+		return patchElement(path, v, position, func(elem reflect.Value) error {
+			return applyDeltasToValue(childPosition(path, position), d.Deltas, elem)
+		})
+	case *Added:
+		return setElement(path, v, position, d.Value, true)
+	case *Modified:
+		return setElement(path, v, position, d.NewValue, false)
+	case *TextDiff:
+		return patchString(path, v, position, "text diff on", func(old string) (string, error) {
+			textDiff := *d
+			textDiff.OldValue = old
+			if err := textDiff.patch(); err != nil {
+				return "", err
+			}
+			return textDiff.NewValue.(string), nil
+		})
+	case *Embedded:
+		return patchString(path, v, position, "embedded document in", func(old string) (string, error) {
+			return d.patch(old)
+		})
+	case *Moved:
+		item, ok := moved[d]
+		if !ok {
+			break
+		}
+		switch v.Kind() {
+		case reflect.Slice:
+			i, err := indexOf(path, v, position, v.Len())
+			if err != nil {
+				return err
+			}
+			insertElement(v, i, item)
+		case reflect.Map:
+			key, err := mapKeyOf(path, v, position)
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(key, item)
+		default:
+			return &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: fmt.Errorf("%w: cannot apply %T", ErrTypeMismatch, delta)}
+		}
+		if nested, ok := d.Delta.(PostDelta); ok {
+			return applyPostDeltaToValue(path, nested, v, moved)
+		}
+		return nil
+	}
+	return &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: fmt.Errorf("%w: cannot apply %T", ErrTypeMismatch, delta)}
+}
+
+// patchElement calls patch with a settable element at the position and
+// stores the element back when it is a copy.
+func patchElement(path []Position, v reflect.Value, position Position, patch func(elem reflect.Value) error) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		field, err := structFieldOf(path, v, position)
+		if err != nil {
+			return err
+		}
+		return patch(field.value)
+	case reflect.Map:
+		key, err := mapKeyOf(path, v, position)
+		if err != nil {
+			return err
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := patch(elem); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	case reflect.Slice, reflect.Array:
+		i, err := indexOf(path, v, position, v.Len()-1)
+		if err != nil {
+			return err
+		}
+		return patch(v.Index(i))
+	}
+	return &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: ErrTypeMismatch}
+}
+
+// patchString applies the patch to the string at the position, which is
+// either a string or a value encoded as a JSON string by its methods.
+func patchString(path []Position, v reflect.Value, position Position, description string, patch func(old string) (string, error)) error {
+	elemPath := childPosition(path, position)
+	return patchElement(path, v, position, func(elem reflect.Value) error {
+		patchValue := func(value interface{}) (interface{}, error) {
+			old, ok := value.(string)
+			if !ok {
+				return nil, &PatchError{Path: elemPath, Type: elem.Type(), Err: fmt.Errorf("%w: %s a non-string value", ErrTypeMismatch, description)}
+			}
+			patched, err := patch(old)
+			if err != nil {
+				return nil, &PatchError{Path: elemPath, Type: elem.Type(), Err: fmt.Errorf("%w: %s", ErrTypeMismatch, err)}
+			}
+			return patched, nil
+		}
+		if isMarshaled(elem) {
+			return patchMarshaled(elemPath, elem, patchValue)
+		}
+		if elem.Kind() == reflect.Interface && !elem.IsNil() && elem.Elem().Kind() == reflect.String {
+			patched, err := patchValue(elem.Elem().String())
+			if err != nil {
+				return err
+			}
+			elem.Set(reflect.ValueOf(patched).Convert(elem.Elem().Type()))
+			return nil
+		}
+		if elem.Kind() != reflect.String {
+			_, err := patchValue(nil)
+			return err
+		}
+		patched, err := patchValue(elem.String())
+		if err != nil {
+			return err
+		}
+		elem.SetString(patched.(string))
+		return nil
+	})
+}
+
+// isMarshaled reports whether the value is encoded by its own methods, such
+// as time.Time and json.RawMessage. Such values are patched as their JSON
+// values.
+func isMarshaled(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface {
+		return false
+	}
+	_, ok := asMarshaler(v, marshalerType)
+	if !ok {
+		_, ok = asMarshaler(v, textMarshalerType)
+	}
+	return ok
+}
+
+// patchMarshaled applies the patch to the JSON value of the settable value v
+// as CompareValues encodes it, and decodes the patched JSON value into v.
+func patchMarshaled(path []Position, v reflect.Value, patch func(value interface{}) (interface{}, error)) error {
+	value, err := jsonValue(v)
+	if err != nil {
+		return &PatchError{Path: path, Type: v.Type(), Err: fmt.Errorf("%w: %s", ErrTypeMismatch, err)}
+	}
+	patched, err := patch(value)
+	if err != nil {
+		return err
+	}
+	converted, err := convertValue(path, patched, v.Type(), false)
+	if err != nil {
+		return err
+	}
+	v.Set(converted)
+	return nil
+}
+
+func setElement(path []Position, v reflect.Value, position Position, value interface{}, insert bool) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		field, err := structFieldOf(path, v, position)
+		if err != nil {
+			return err
+		}
+		converted, err := convertValue(childPosition(path, position), value, field.value.Type(), field.quoted)
+		if err != nil {
+			return err
+		}
+		field.value.Set(converted)
+		return nil
+	case reflect.Map:
+		key, err := mapKeyOf(path, v, position)
+		if err != nil {
+			return err
+		}
+		converted, err := convertValue(childPosition(path, position), value, v.Type().Elem(), false)
+		if err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(key, converted)
+		return nil
+	case reflect.Slice, reflect.Array:
+		last := v.Len() - 1
+		if insert {
+			if v.Kind() == reflect.Array {
+				break
+			}
+			last = v.Len()
+		}
+		i, err := indexOf(path, v, position, last)
+		if err != nil {
+			return err
+		}
+		converted, err := convertValue(childPosition(path, position), value, v.Type().Elem(), false)
+		if err != nil {
+			return err
+		}
+		if insert {
+			insertElement(v, i, converted)
+		} else {
+			v.Index(i).Set(converted)
+		}
+		return nil
+	}
+	return &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: ErrTypeMismatch}
+}
+
+func insertElement(slice reflect.Value, i int, item reflect.Value) {
+	slice.Set(reflect.Append(slice, reflect.Zero(slice.Type().Elem())))
+	reflect.Copy(slice.Slice(i+1, slice.Len()), slice.Slice(i, slice.Len()-1))
+	slice.Index(i).Set(item)
+}
+
+// convertValue converts a value in the data model of encoding/json into the
+// type as encoding/json decodes it.
+func convertValue(path []Position, value interface{}, t reflect.Type, quoted bool) (reflect.Value, error) {
+	var bytes []byte
+	var err error
+	if s, ok := value.(string); ok && quoted {
+		bytes = []byte(s)
+	} else if bytes, err = json.Marshal(value); err != nil {
+		return reflect.Value{}, &PatchError{Path: path, Type: t, Err: fmt.Errorf("%w: %s", ErrTypeMismatch, err)}
+	}
+	converted := reflect.New(t)
+	if err := json.Unmarshal(bytes, converted.Interface()); err != nil {
+		return reflect.Value{}, &PatchError{Path: path, Type: t, Err: fmt.Errorf("%w: %s", ErrTypeMismatch, err)}
+	}
+	return converted.Elem(), nil
+}
+
+type patchField struct {
+	value  reflect.Value
+	quoted bool
+}
+
+// structFieldOf returns the settable field of the struct for the position,
+// allocating nil embedded structs.
+func structFieldOf(path []Position, v reflect.Value, position Position) (patchField, error) {
+	name, ok := position.(Name)
+	if !ok {
+		return patchField{}, &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: fmt.Errorf("%w: index on a struct", ErrTypeMismatch)}
+	}
+	for _, field := range cachedFields(v.Type()) {
+		if field.name != string(name) {
+			continue
+		}
+		value := v
+		for i, x := range field.index {
+			if i > 0 && value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					if !value.CanSet() {
+						return patchField{}, &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: fmt.Errorf("%w: cannot set embedded pointer to unexported struct %s", ErrTypeMismatch, value.Type().Elem())}
+					}
+					value.Set(reflect.New(value.Type().Elem()))
+				}
+				value = value.Elem()
+			}
+			value = value.Field(x)
+		}
+		if !value.CanSet() {
+			break
+		}
+		return patchField{value: value, quoted: field.quoted}, nil
+	}
+	return patchField{}, &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: ErrFieldNotFound}
+}
+
+func mapKeyOf(path []Position, v reflect.Value, position Position) (reflect.Value, error) {
+	name, ok := position.(Name)
+	if !ok {
+		return reflect.Value{}, &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: fmt.Errorf("%w: index on a map", ErrTypeMismatch)}
+	}
+	keyType := v.Type().Key()
+	key := reflect.New(keyType)
+	var err error
+	switch {
+	case keyType.Kind() == reflect.String:
+		key.Elem().SetString(string(name))
+	case key.Type().Implements(textUnmarshalerType):
+		err = key.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(name))
+	case keyType.Kind() >= reflect.Int && keyType.Kind() <= reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(string(name), 10, 64); err == nil {
+			key.Elem().SetInt(n)
+		}
+	case keyType.Kind() >= reflect.Uint && keyType.Kind() <= reflect.Uintptr:
+		var n uint64
+		if n, err = strconv.ParseUint(string(name), 10, 64); err == nil {
+			key.Elem().SetUint(n)
+		}
+	default:
+		err = fmt.Errorf("unsupported map key type %s", keyType)
+	}
+	if err != nil {
+		return reflect.Value{}, &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: fmt.Errorf("%w: %s", ErrTypeMismatch, err)}
+	}
+	return key.Elem(), nil
+}
+
+func indexOf(path []Position, v reflect.Value, position Position, last int) (int, error) {
+	index, ok := position.(Index)
+	if !ok {
+		return 0, &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: fmt.Errorf("%w: key on a %s", ErrTypeMismatch, v.Kind())}
+	}
+	if int(index) < 0 || int(index) > last {
+		return 0, &PatchError{Path: childPosition(path, position), Type: v.Type(), Err: ErrIndexOutOfRange}
+	}
+	return int(index), nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// copyValue returns a deep copy of the value, so that patching the copy does
+// not modify the original. Unexported fields are copied shallowly.
+func copyValue(v reflect.Value) reflect.Value {
+	return copyValueVisited(v, map[uintptr]reflect.Value{})
+}
+
+func copyValueVisited(v reflect.Value, visited map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		if copied, ok := visited[v.Pointer()]; ok {
+			return copied // cycles
+		}
+		copied := reflect.New(v.Type().Elem())
+		visited[v.Pointer()] = copied
+		copied.Elem().Set(copyValueVisited(v.Elem(), visited))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(copyValueVisited(v.Elem(), visited))
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(copyValueVisited(v.Index(i), visited))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(copyValueVisited(v.Index(i), visited))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), copyValueVisited(iter.Value(), visited))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if field := copied.Field(i); field.CanSet() {
+				field.Set(copyValueVisited(v.Field(i), visited))
+			}
+		}
+		return copied
+	}
+	return v
+}
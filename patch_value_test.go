@@ -0,0 +1,170 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+type PatchBase struct {
+	ID int `json:"id"`
+}
+
+type patchItem struct {
+	*PatchBase
+	Name     string            `json:"name"`
+	Count    int64             `json:"count,string"`
+	Tags     []string          `json:"tags"`
+	Scores   map[int]float32   `json:"scores"`
+	Created  time.Time         `json:"created"`
+	Next     *patchItem        `json:"next,omitempty"`
+	Extra    interface{}       `json:"extra"`
+	Children []patchChild      `json:"children"`
+	Labels   map[string]string `json:"labels"`
+}
+
+type patchChild struct {
+	Key   string `json:"key"`
+	Value int    `json:"value"`
+}
+
+type patchDiff []Delta
+
+func (d patchDiff) Deltas() []Delta { return d }
+func (d patchDiff) Modified() bool  { return len(d) > 0 }
+
+var _ = Describe("ApplyPatchToValue", func() {
+	var differ *Differ
+
+	BeforeEach(func() {
+		differ = New()
+	})
+
+	It("applies diffs of Go values", func() {
+		left := &patchItem{
+			Name:     "left",
+			Count:    1,
+			Tags:     []string{"a", "b", "c", "d"},
+			Scores:   map[int]float32{1: 0.5, 2: 1},
+			Created:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			Extra:    map[string]interface{}{"k": []interface{}{1.0}},
+			Children: []patchChild{{"a", 1}, {"b", 2}, {"c", 3}},
+			Labels:   map[string]string{"env": "dev"},
+		}
+		right := &patchItem{
+			PatchBase: &PatchBase{ID: 7},
+			Name:      "right",
+			Count:     2,
+			Tags:      []string{"d", "a", "c", "e"},
+			Scores:    map[int]float32{2: 1.5, 3: 2},
+			Created:   time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+			Next:      &patchItem{Name: "next"},
+			Extra:     map[string]interface{}{"k": []interface{}{1.0, 2.0}},
+			Children:  []patchChild{{"a", 1}, {"c", 4}},
+			Labels:    map[string]string{"env": "prod", "team": "x"},
+		}
+
+		d, err := differ.CompareValues(left, right)
+		Expect(err).To(BeNil())
+		Expect(differ.ApplyPatchToValue(left, d)).To(Succeed())
+		Expect(left).To(Equal(right))
+	})
+
+	It("applies text diffs to string fields", func() {
+		long := strings.Repeat("lorem ipsum ", 10)
+		left := &patchItem{Name: long + "dolor"}
+		right := &patchItem{Name: long + "dolorem"}
+
+		d, err := differ.CompareValues(left, right)
+		Expect(err).To(BeNil())
+		_, ok := d.Deltas()[0].(*TextDiff)
+		Expect(ok).To(BeTrue())
+		Expect(differ.ApplyPatchToValue(left, d)).To(Succeed())
+		Expect(left.Name).To(Equal(right.Name))
+	})
+
+	It("applies text diffs to strings in interfaces", func() {
+		long := strings.Repeat("lorem ipsum ", 10)
+		left := map[string]interface{}{"c": long + "dolor"}
+		right := map[string]interface{}{"c": long + "dolorem"}
+
+		d := differ.CompareObjects(left, right)
+		_, ok := d.Deltas()[0].(*TextDiff)
+		Expect(ok).To(BeTrue())
+		Expect(differ.ApplyPatchToValue(&left, d)).To(Succeed())
+		Expect(left).To(Equal(right))
+
+		item := &patchItem{Extra: long + "dolor"}
+		d, err := differ.CompareValues(item, &patchItem{Extra: long + "dolorem"})
+		Expect(err).To(BeNil())
+		Expect(differ.ApplyPatchToValue(item, d)).To(Succeed())
+		Expect(item.Extra).To(Equal(long + "dolorem"))
+	})
+
+	It("applies diffs to values encoded by their methods", func() {
+		type event struct {
+			At      time.Time       `json:"at"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		left := &event{
+			At:      time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.UTC),
+			Payload: json.RawMessage(`{"user":{"name":"a","roles":["x"]}}`),
+		}
+		right := &event{
+			At:      time.Date(2020, 1, 2, 3, 4, 5, 123456788, time.UTC),
+			Payload: json.RawMessage(`{"user":{"name":"b","roles":["x","y"]}}`),
+		}
+
+		d, err := differ.CompareValues(left, right)
+		Expect(err).To(BeNil())
+		Expect(d.Deltas()).To(ContainElement(BeAssignableToTypeOf(&TextDiff{})))
+		Expect(d.Deltas()).To(ContainElement(BeAssignableToTypeOf(&Object{})))
+		Expect(differ.ApplyPatchToValue(left, d)).To(Succeed())
+		Expect(left.At.Equal(right.At)).To(BeTrue())
+		Expect(left.Payload).To(MatchJSON(right.Payload))
+	})
+
+	It("applies diffs to maps and slices", func() {
+		left := map[string][]int{"a": {1, 2}}
+		d, err := differ.CompareValues(left, map[string][]int{"a": {1, 3}, "b": {}})
+		Expect(err).To(BeNil())
+		Expect(differ.ApplyPatchToValue(&left, d)).To(Succeed())
+		Expect(left).To(Equal(map[string][]int{"a": {1, 3}, "b": {}}))
+	})
+
+	It("reports unknown fields", func() {
+		d := patchDiff([]Delta{NewAdded(Name("unknown"), 1.0)})
+		err := differ.ApplyPatchToValue(&patchItem{}, d)
+
+		Expect(errors.Is(err, ErrFieldNotFound)).To(BeTrue())
+		var patchErr *PatchError
+		Expect(errors.As(err, &patchErr)).To(BeTrue())
+		Expect(JSONPointer(patchErr.Path)).To(Equal("/unknown"))
+	})
+
+	It("reports values of wrong types", func() {
+		d := patchDiff([]Delta{NewModified(Name("tags"), nil, "text")})
+		err := differ.ApplyPatchToValue(&patchItem{}, d)
+
+		Expect(errors.Is(err, ErrTypeMismatch)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`"/tags"`))
+	})
+
+	It("reports indexes out of range", func() {
+		d := patchDiff([]Delta{NewArray(Name("tags"), []Delta{NewDeleted(Index(3), "x")})})
+		err := differ.ApplyPatchToValue(&patchItem{Tags: []string{"a"}}, d)
+
+		Expect(errors.Is(err, ErrIndexOutOfRange)).To(BeTrue())
+	})
+
+	It("requires a pointer", func() {
+		d := patchDiff([]Delta{})
+		Expect(errors.Is(differ.ApplyPatchToValue(patchItem{}, d), ErrTypeMismatch)).To(BeTrue())
+	})
+})
@@ -0,0 +1,413 @@
+package gojsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yudai/golcs"
+)
+
+// RedactedValue replaces values of fields tagged with `diff:"redact"` in
+// Deltas. Diffs with redacted values cannot be applied.
+var RedactedValue interface{} = "[redacted]"
+
+// A valuePolicy holds the directives of `diff` struct tags for a value, and
+// the policies of its fields or elements. A nil policy compares values as
+// usual.
+type valuePolicy struct {
+	ignore    bool
+	redact    bool
+	set       bool
+	key       string
+	tolerance float64
+
+	fields map[string]*valuePolicy // by JSON names, for structs
+	elem   *valuePolicy            // for slices, arrays and maps
+}
+
+var policyCache sync.Map // map[reflect.Type]policyResult
+
+type policyResult struct {
+	policy *valuePolicy
+	err    error
+}
+
+// typePolicy returns the policy for values of the type. The result is nil
+// when the type has no `diff` tags.
+func typePolicy(t reflect.Type) (*valuePolicy, error) {
+	if t == nil {
+		return nil, nil
+	}
+	if result, ok := policyCache.Load(t); ok {
+		return result.(policyResult).policy, result.(policyResult).err
+	}
+	b := &policyBuilder{building: map[reflect.Type]*valuePolicy{}}
+	policy, err := b.build(t)
+	if err == nil && !b.tagged {
+		policy = nil
+	}
+	result, _ := policyCache.LoadOrStore(t, policyResult{policy: policy, err: err})
+	return result.(policyResult).policy, result.(policyResult).err
+}
+
+type policyBuilder struct {
+	building map[reflect.Type]*valuePolicy
+	tagged   bool
+}
+
+func (b *policyBuilder) build(t reflect.Type) (*valuePolicy, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if policy, ok := b.building[t]; ok {
+		return policy, nil // recursive types
+	}
+	if t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return nil, nil
+	}
+
+	policy := &valuePolicy{}
+	b.building[t] = policy
+	defer delete(b.building, t)
+
+	switch t.Kind() {
+	case reflect.Struct:
+		policy.fields = map[string]*valuePolicy{}
+		for _, field := range cachedFields(t) {
+			sf := t.FieldByIndex(field.index)
+			fieldPolicy, err := b.build(sf.Type)
+			if err != nil {
+				return nil, err
+			}
+			if tag, ok := sf.Tag.Lookup("diff"); ok {
+				b.tagged = true
+				if fieldPolicy, err = fieldPolicy.withTag(tag, sf.Type); err != nil {
+					return nil, fmt.Errorf("invalid diff tag of %s.%s: %s", t, sf.Name, err)
+				}
+			}
+			if fieldPolicy != nil {
+				policy.fields[field.name] = fieldPolicy
+			}
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		elem, err := b.build(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		policy.elem = elem
+	}
+	return policy, nil
+}
+
+// withTag returns a copy of the policy with the directives of the tag.
+// Tolerances also apply to the elements of slices, arrays and maps.
+func (p *valuePolicy) withTag(tag string, t reflect.Type) (*valuePolicy, error) {
+	policy := &valuePolicy{}
+	if p != nil {
+		*policy = *p
+	}
+	for _, directive := range strings.Split(tag, ",") {
+		name, value := directive, ""
+		if i := strings.Index(directive, "="); i >= 0 {
+			name, value = directive[:i], directive[i+1:]
+		}
+		switch name {
+		case "-":
+			policy.ignore = true
+		case "redact":
+			policy.redact = true
+		case "set":
+			policy.set = true
+		case "key":
+			key, err := elementKey(t, value)
+			if err != nil {
+				return nil, err
+			}
+			policy.key = key
+		case "tolerance":
+			tolerance, err := strconv.ParseFloat(value, 64)
+			if err != nil || tolerance < 0 {
+				return nil, fmt.Errorf("invalid tolerance %q", value)
+			}
+			for q := policy; q != nil; q = q.elem {
+				q.tolerance = tolerance
+				if q.elem != nil {
+					elem := *q.elem
+					q.elem = &elem
+				}
+				if q.fields != nil {
+					break
+				}
+			}
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown directive %q", directive)
+		}
+	}
+	return policy, nil
+}
+
+// elementKey returns the JSON name of the key field of the elements of the
+// slice type. The key can be given as the Go name or the JSON name.
+func elementKey(t reflect.Type, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("empty key")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return "", fmt.Errorf("key on %s", t)
+	}
+	elem := t.Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return key, nil // maps and interfaces are keyed by JSON names
+	}
+	for _, field := range cachedFields(elem) {
+		if field.name == key || elem.FieldByIndex(field.index).Name == key {
+			return field.name, nil
+		}
+	}
+	return "", fmt.Errorf("no field %q in %s", key, elem)
+}
+
+func (p *valuePolicy) field(name string) *valuePolicy {
+	if p == nil {
+		return nil
+	}
+	if p.fields != nil {
+		return p.fields[name]
+	}
+	return p.elem
+}
+
+func (p *valuePolicy) element() *valuePolicy {
+	if p == nil {
+		return nil
+	}
+	return p.elem
+}
+
+func (p *valuePolicy) redacted() bool {
+	return p != nil && p.redact
+}
+
+func (p *valuePolicy) unordered() bool {
+	return p != nil && p.set
+}
+
+func (p *valuePolicy) keyed() bool {
+	return p != nil && p.key != ""
+}
+
+func (p *valuePolicy) tolerates(left, right interface{}) bool {
+	if p == nil || p.tolerance == 0 {
+		return false
+	}
+	l, ok := left.(float64)
+	if !ok {
+		return false
+	}
+	r, ok := right.(float64)
+	return ok && math.Abs(l-r) <= p.tolerance
+}
+
+// hide returns the value with redacted values replaced with RedactedValue.
+func (p *valuePolicy) hide(value interface{}) interface{} {
+	if p == nil {
+		return value
+	}
+	if p.redact {
+		return RedactedValue
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		hidden := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			hidden[name] = p.field(name).hide(item)
+		}
+		return hidden
+	case []interface{}:
+		hidden := make([]interface{}, len(v))
+		for i, item := range v {
+			hidden[i] = p.elem.hide(item)
+		}
+		return hidden
+	}
+	return value
+}
+
+// prune removes ignored fields from the value in place.
+func (p *valuePolicy) prune(value interface{}) interface{} {
+	if p == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, item := range v {
+			child := p.field(name)
+			if child != nil && child.ignore {
+				delete(v, name)
+				continue
+			}
+			v[name] = child.prune(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = p.elem.prune(item)
+		}
+	}
+	return value
+}
+
+func (differ *Differ) compareRedacted(
+	path []Position,
+	position Position,
+	left interface{},
+	right interface{},
+	policy *valuePolicy,
+) (same bool, delta Delta) {
+	unredacted := *policy
+	unredacted.redact = false
+	if same, _ := differ.compareValues(path, position, left, right, &unredacted); same {
+		return true, nil
+	}
+	return false, NewModified(position, RedactedValue, RedactedValue)
+}
+
+// compareSets compares arrays as multisets. Elements without counterparts
+// are reported as deleted or added.
+func (differ *Differ) compareSets(
+	path []Position,
+	left []interface{},
+	right []interface{},
+	elem *valuePolicy,
+) (deltas []Delta) {
+	deltas = make([]Delta, 0)
+	plainLeft, plainRight := plainValues(left), plainValues(right)
+	matched := make([]bool, len(right))
+	// large sets are matched only by equality
+	tolerant := !differ.exceedsSimilarityCells(len(left) * len(right))
+	for i, leftValue := range plainLeft {
+		found := false
+		for j, rightValue := range plainRight {
+			if matched[j] {
+				continue
+			}
+			if reflect.DeepEqual(leftValue, rightValue) {
+				found = true
+			} else if tolerant && !differ.budget.exhausted() {
+				found, _ = differ.compareValues(path, Index(j), leftValue, rightValue, elem)
+			}
+			if found {
+				matched[j] = true
+				break
+			}
+		}
+		if !found {
+			deltas = append(deltas, NewDeleted(Index(i), elem.hide(left[i])))
+		}
+	}
+	for j, m := range matched {
+		if !m {
+			deltas = append(deltas, NewAdded(Index(j), elem.hide(right[j])))
+		}
+	}
+	return deltas
+}
+
+// missingKey stands for the key of an element without the key field,
+// which matches no other element.
+type missingKey struct {
+	right bool
+	index int
+}
+
+// compareKeyedArrays aligns the elements of arrays by their keys.
+// Elements with the same key are compared, and moved when their order changed.
+func (differ *Differ) compareKeyedArrays(
+	path []Position,
+	left []interface{},
+	right []interface{},
+	policy *valuePolicy,
+) (deltas []Delta) {
+	deltas = make([]Delta, 0)
+	elem := policy.element()
+	leftKeys := arrayKeys(left, policy.key, false)
+	rightKeys := arrayKeys(right, policy.key, true)
+	lcsPairs := lcs.New(leftKeys, rightKeys).IndexPairs()
+
+	inLCSLeft := make([]bool, len(left))
+	inLCSRight := make([]bool, len(right))
+	for _, pair := range lcsPairs {
+		inLCSLeft[pair.Left] = true
+		inLCSRight[pair.Right] = true
+		same, delta := differ.compareValues(path, Index(pair.Right), left[pair.Left], right[pair.Right], elem)
+		if !same {
+			deltas = append(deltas, delta)
+		}
+	}
+
+	rightByKey := map[interface{}][]int{}
+	for j, key := range rightKeys {
+		if !inLCSRight[j] {
+			rightByKey[key] = append(rightByKey[key], j)
+		}
+	}
+	moved := make([]bool, len(right))
+	for i, key := range leftKeys {
+		if inLCSLeft[i] {
+			continue
+		}
+		if candidates := rightByKey[key]; len(candidates) > 0 {
+			j := candidates[0]
+			rightByKey[key] = candidates[1:]
+			moved[j] = true
+			var inner Delta
+			if same, delta := differ.compareValues(path, Index(j), left[i], right[j], elem); !same {
+				inner = delta
+			}
+			deltas = append(deltas, NewMoved(Index(i), Index(j), elem.hide(left[i]), inner))
+		} else {
+			deltas = append(deltas, NewDeleted(Index(i), elem.hide(left[i])))
+		}
+	}
+	for j := range right {
+		if !inLCSRight[j] && !moved[j] {
+			deltas = append(deltas, NewAdded(Index(j), elem.hide(right[j])))
+		}
+	}
+	return deltas
+}
+
+// arrayKeys returns the keys of the elements as their JSON encodings.
+func arrayKeys(array []interface{}, key string, right bool) []interface{} {
+	keys := make([]interface{}, len(array))
+	for i, item := range array {
+		keys[i] = missingKey{right: right, index: i}
+		var value interface{}
+		var ok bool
+		switch object := item.(type) {
+		case map[string]interface{}:
+			value, ok = object[key]
+		case *OrderedMap:
+			value, ok = object.Get(key)
+		}
+		if !ok {
+			continue
+		}
+		if bytes, err := json.Marshal(value); err == nil {
+			keys[i] = string(bytes)
+		}
+	}
+	return keys
+}
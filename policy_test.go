@@ -0,0 +1,141 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type policyPort struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type policyService struct {
+	Name      string         `json:"name"`
+	UpdatedAt string         `json:"updatedAt" diff:"-"`
+	Ports     []policyPort   `json:"ports" diff:"key=ID"`
+	Tags      []string       `json:"tags" diff:"set"`
+	Load      float64        `json:"load" diff:"tolerance=0.01"`
+	Weights   []float64      `json:"weights" diff:"tolerance=0.5"`
+	Password  string         `json:"password" diff:"redact"`
+	Next      *policyService `json:"next,omitempty"`
+}
+
+type policyInvalid struct {
+	Name string `diff:"unknown"`
+}
+
+var _ = Describe("Struct tag policies", func() {
+	var differ *Differ
+
+	BeforeEach(func() {
+		differ = New()
+	})
+
+	service := func() policyService {
+		return policyService{
+			Name:      "api",
+			UpdatedAt: "yesterday",
+			Ports:     []policyPort{{1, "http"}, {2, "https"}, {3, "admin"}},
+			Tags:      []string{"a", "b", "c"},
+			Load:      0.5,
+			Weights:   []float64{1, 2},
+			Password:  "old",
+		}
+	}
+
+	It("ignores fields, tolerances and set orders", func() {
+		left, right := service(), service()
+		right.UpdatedAt = "today"
+		right.Tags = []string{"c", "a", "b"}
+		right.Load = 0.505
+		right.Weights = []float64{1.2, 2.4}
+
+		d, err := differ.CompareValues(left, right)
+		Expect(err).To(BeNil())
+		Expect(d.Modified()).To(BeFalse())
+	})
+
+	It("reports changes of sets", func() {
+		left, right := service(), service()
+		right.Tags = []string{"c", "d", "a"}
+
+		d, err := differ.CompareValues(left, right)
+		Expect(err).To(BeNil())
+		Expect(d.Deltas()).To(HaveLen(1))
+		deltas := d.Deltas()[0].(*Array).Deltas
+		Expect(deltas).To(Equal([]Delta{
+			NewDeleted(Index(1), "b"),
+			NewAdded(Index(1), "d"),
+		}))
+	})
+
+	It("matches slice elements by keys", func() {
+		left, right := service(), service()
+		right.Ports = []policyPort{{3, "admin"}, {1, "http"}, {2, "tls"}, {4, "new"}}
+
+		d, err := differ.CompareValues(left, right)
+		Expect(err).To(BeNil())
+		deltas := d.Deltas()[0].(*Array).Deltas
+		Expect(deltas).To(HaveLen(3))
+
+		modified := deltas[0].(*Object)
+		Expect(modified.Position).To(Equal(Index(2)))
+		Expect(modified.Deltas[0].(*Modified).NewValue).To(Equal("tls"))
+		moved := deltas[1].(*Moved)
+		Expect(moved.PrePosition()).To(Equal(Index(2)))
+		Expect(moved.PostPosition()).To(Equal(Index(0)))
+		Expect(deltas[2].(*Added).Position).To(Equal(Index(3)))
+
+		leftValue, _ := JSONValue(left)
+		rightValue, _ := JSONValue(right)
+		patched := leftValue.(map[string]interface{})
+		differ.ApplyPatch(patched, d)
+		delete(patched, "updatedAt")
+		delete(rightValue.(map[string]interface{}), "updatedAt")
+		Expect(patched).To(Equal(rightValue))
+	})
+
+	It("redacts values", func() {
+		left, right := service(), service()
+		right.Password = "new"
+		next := service()
+		right.Next = &next
+
+		d, err := differ.CompareValues(left, right)
+		Expect(err).To(BeNil())
+		Expect(d.Deltas()).To(ContainElement(NewModified(Name("password"), RedactedValue, RedactedValue)))
+		for _, delta := range d.Deltas() {
+			if added, ok := delta.(*Added); ok {
+				Expect(added.Value.(map[string]interface{})["password"]).To(Equal(RedactedValue))
+				Expect(added.Value.(map[string]interface{})).NotTo(HaveKey("updatedAt"))
+			}
+		}
+	})
+
+	It("redacts values reported by hooks", func() {
+		left, right := service(), service()
+		right.Password = "new"
+		hook := func(path []Position, l, r interface{}) (Delta, bool) {
+			if _, ok := l.(string); ok && l != r {
+				return NewModified(path[len(path)-1], l, r), true
+			}
+			return nil, false
+		}
+
+		d, err := differ.With(WithCompareHook(hook)).CompareValues(left, right)
+		Expect(err).To(BeNil())
+		Expect(d.Deltas()).To(Equal([]Delta{NewModified(Name("password"), RedactedValue, RedactedValue)}))
+
+		d, err = differ.With(WithCompareHook(hook)).CompareValues(left, service())
+		Expect(err).To(BeNil())
+		Expect(d.Modified()).To(BeFalse())
+	})
+
+	It("reports invalid tags", func() {
+		_, err := differ.CompareValues(policyInvalid{}, policyInvalid{})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,105 @@
+package gojsondiff
+
+import (
+	"reflect"
+	"sort"
+)
+
+// WithRenameDetection makes the Differ report keys of objects renamed with
+// the same or similar values as Moved with Name positions, instead of
+// Deleted and Added. The threshold, from 0 to 1, is the minimum similarity
+// of the values. Scalar values must be the same, and objects and arrays are
+// similar by their Deltas. Zero disables the detection, which is the default.
+func WithRenameDetection(threshold float64) Option {
+	return func(differ *Differ) {
+		differ.renameThreshold = threshold
+	}
+}
+
+// renames holds renamed keys of an object.
+type renames struct {
+	// from holds Moved by the old names
+	from map[string]*Moved
+	// to holds the new names
+	to map[string]bool
+}
+
+type renameCandidate struct {
+	from, to   int
+	similarity float64
+	delta      Delta
+}
+
+// detectRenames pairs keys only in the left object with keys only in the
+// right object whose values are similar enough. Pairs are chosen from the
+// most similar ones.
+func (differ *Differ) detectRenames(
+	path []Position,
+	leftNames []string,
+	rightNames []string,
+	left map[string]interface{},
+	right map[string]interface{},
+	policy *valuePolicy,
+) renames {
+	result := renames{}
+	if differ.renameThreshold <= 0 {
+		return result
+	}
+	deleted, added := missingNames(leftNames, right), missingNames(rightNames, left)
+	if len(deleted) == 0 || len(added) == 0 ||
+		differ.budget.exhausted() || differ.exceedsSimilarityCells(len(deleted)*len(added)) {
+		return result
+	}
+
+	candidates := make([]renameCandidate, 0)
+	for i, from := range deleted {
+		for j, to := range added {
+			leftValue, rightValue := left[from], right[to]
+			if reflect.TypeOf(leftValue) != reflect.TypeOf(rightValue) {
+				continue
+			}
+			candidate := renameCandidate{from: i, to: j, similarity: 1}
+			same, delta := differ.compareValues(path, Name(to), leftValue, rightValue, policy.field(to))
+			if !same {
+				switch delta.(type) {
+				case *Object, *Array, *Embedded:
+					candidate.similarity = delta.Similarity()
+					candidate.delta = delta
+				default:
+					continue
+				}
+			}
+			if candidate.similarity >= differ.renameThreshold {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+
+	result = renames{from: map[string]*Moved{}, to: map[string]bool{}}
+	usedFrom := make([]bool, len(deleted))
+	usedTo := make([]bool, len(added))
+	for _, candidate := range candidates {
+		if usedFrom[candidate.from] || usedTo[candidate.to] {
+			continue
+		}
+		usedFrom[candidate.from], usedTo[candidate.to] = true, true
+		from, to := deleted[candidate.from], added[candidate.to]
+		result.from[from] = NewMoved(Name(from), Name(to), policy.field(from).hide(left[from]), candidate.delta)
+		result.to[to] = true
+	}
+	return result
+}
+
+// missingNames returns the names not in the other object.
+func missingNames(names []string, other map[string]interface{}) []string {
+	missing := make([]string, 0)
+	for _, name := range names {
+		if _, ok := other[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,69 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rename detection", func() {
+	left := func() map[string]interface{} {
+		return map[string]interface{}{
+			"userName": "alice",
+			"address":  map[string]interface{}{"city": "Tokyo", "zip": "100", "country": "JP"},
+			"removed":  1.0,
+		}
+	}
+	right := func() map[string]interface{} {
+		return map[string]interface{}{
+			"user_name": "alice",
+			"location":  map[string]interface{}{"city": "Tokyo", "zip": "101", "country": "JP"},
+			"added":     2.0,
+		}
+	}
+
+	It("reports renamed keys as moved", func() {
+		deltas := New(WithRenameDetection(0.5)).CompareObjects(left(), right()).Deltas()
+		Expect(deltas).To(HaveLen(4))
+		Expect(deltas).To(ContainElement(NewMoved(Name("userName"), Name("user_name"), "alice", nil)))
+		Expect(deltas).To(ContainElement(NewDeleted(Name("removed"), 1.0)))
+		Expect(deltas).To(ContainElement(NewAdded(Name("added"), 2.0)))
+
+		for _, delta := range deltas {
+			if moved, ok := delta.(*Moved); ok && moved.PrePosition() == Name("address") {
+				Expect(moved.PostPosition()).To(Equal(Name("location")))
+				Expect(moved.Delta.(*Object).Deltas).To(Equal([]Delta{NewModified(Name("zip"), "100", "101")}))
+			}
+		}
+	})
+
+	It("keeps dissimilar values deleted and added", func() {
+		deltas := New(WithRenameDetection(1)).CompareObjects(left(), right()).Deltas()
+		Expect(deltas).To(HaveLen(5))
+		deltas = New().CompareObjects(left(), right()).Deltas()
+		Expect(deltas).To(HaveLen(6))
+	})
+
+	It("patches renamed keys", func() {
+		d := New(WithRenameDetection(0.5)).CompareObjects(left(), right())
+		patched := left()
+		New().ApplyPatch(patched, d)
+		Expect(patched).To(Equal(right()))
+
+		ordered := NewOrderedMap()
+		for _, name := range []string{"userName", "address", "removed"} {
+			ordered.Set(name, left()[name])
+		}
+		New().ApplyPatchToOrderedMap(ordered, d)
+		Expect(ordered.Keys()).To(ConsistOf("user_name", "location", "added"))
+
+		value := map[string]map[string]string{"address": {"city": "Tokyo", "zip": "100", "country": "JP"}}
+		d = New(WithRenameDetection(0.5)).CompareObjects(
+			map[string]interface{}{"address": left()["address"]},
+			map[string]interface{}{"location": right()["location"]},
+		)
+		Expect(New().ApplyPatchToValue(&value, d)).To(Succeed())
+		Expect(value).To(Equal(map[string]map[string]string{"location": {"city": "Tokyo", "zip": "101", "country": "JP"}}))
+	})
+})
@@ -0,0 +1,98 @@
+package gojsondiff
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A SourcePosition is a position in a JSON document.
+type SourcePosition struct {
+	// Offset is the byte offset from the beginning of the document
+	Offset int
+	// Line and Column are 1-based, Column is counted in characters
+	Line   int
+	Column int
+}
+
+// A SourceMap holds the positions of values in a JSON document by their
+// JSON Pointers (RFC 6901), such as "/spec/items/0". The position of an
+// object member is the position of its key.
+type SourceMap map[string]SourcePosition
+
+// Lookup returns the position of the value at the path.
+func (m SourceMap) Lookup(path []Position) (position SourcePosition, ok bool) {
+	position, ok = m[JSONPointer(path)]
+	return
+}
+
+// A SourceLocator provides the positions of values in the compared documents.
+// Diffs returned by Compare implement this interface when
+// Differ.RecordPositions is set.
+type SourceLocator interface {
+	LeftSourceMap() SourceMap
+	RightSourceMap() SourceMap
+}
+
+type locatedDiff struct {
+	*diff
+	left  SourceMap
+	right SourceMap
+}
+
+func (d *locatedDiff) LeftSourceMap() SourceMap {
+	return d.left
+}
+
+func (d *locatedDiff) RightSourceMap() SourceMap {
+	return d.right
+}
+
+// JSONPointer returns the JSON Pointer (RFC 6901) of the path.
+func JSONPointer(path []Position) string {
+	tokens := make([]string, len(path))
+	for i, position := range path {
+		tokens[i] = position.String()
+	}
+	return joinPointer(tokens)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func joinPointer(tokens []string) string {
+	pointer := make([]byte, 0, 16*len(tokens))
+	for _, token := range tokens {
+		pointer = append(pointer, '/')
+		pointer = append(pointer, pointerEscaper.Replace(token)...)
+	}
+	return string(pointer)
+}
+
+// A sourceCursor computes lines and columns of increasing offsets without
+// scanning the document from the beginning for each offset.
+type sourceCursor struct {
+	data   []byte
+	offset int
+	line   int
+	column int
+}
+
+func newSourceCursor(data []byte) *sourceCursor {
+	return &sourceCursor{data: data, line: 1, column: 1}
+}
+
+func (c *sourceCursor) at(offset int) SourcePosition {
+	if offset < c.offset {
+		c.offset, c.line, c.column = 0, 1, 1
+	}
+	for c.offset < offset {
+		r, size := utf8.DecodeRune(c.data[c.offset:])
+		if r == '\n' {
+			c.line++
+			c.column = 1
+		} else {
+			c.column++
+		}
+		c.offset += size
+	}
+	return SourcePosition{Offset: c.offset, Line: c.line, Column: c.column}
+}
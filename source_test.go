@@ -0,0 +1,60 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Source", func() {
+	It("Builds JSON Pointers of paths", func() {
+		Expect(JSONPointer([]Position{})).To(Equal(""))
+		Expect(JSONPointer([]Position{Name("a/b"), Index(2), Name("m~n")})).To(Equal("/a~1b/2/m~0n"))
+	})
+
+	Describe("Differ", func() {
+		left := []byte("{\n  \"name\": \"app\",\n  \"tags\": [\"a\", \"b\"],\n  \"debug\": true\n}\n")
+		right := []byte("{\n  \"name\": \"アプリ\", \"tags\": [\"a\",\n    \"c\"]\n}\n")
+
+		It("Records the positions of values in both documents", func() {
+			differ := New()
+			differ.RecordPositions = true
+			diff, err := differ.Compare(left, right)
+			Expect(err).To(BeNil())
+			Expect(diff.Modified()).To(BeTrue())
+
+			locator, ok := diff.(SourceLocator)
+			Expect(ok).To(BeTrue())
+
+			position, ok := locator.LeftSourceMap().Lookup([]Position{Name("debug")})
+			Expect(ok).To(BeTrue())
+			Expect(position).To(Equal(SourcePosition{Offset: 43, Line: 4, Column: 3}))
+
+			position, ok = locator.RightSourceMap().Lookup([]Position{Name("tags"), Index(1)})
+			Expect(ok).To(BeTrue())
+			Expect(position.Line).To(Equal(3))
+			Expect(position.Column).To(Equal(5))
+
+			// columns are counted in characters
+			position, ok = locator.RightSourceMap().Lookup([]Position{Name("tags")})
+			Expect(ok).To(BeTrue())
+			Expect(position.Line).To(Equal(2))
+			Expect(position.Column).To(Equal(18))
+
+			position, ok = locator.RightSourceMap().Lookup([]Position{})
+			Expect(ok).To(BeTrue())
+			Expect(position).To(Equal(SourcePosition{Offset: 0, Line: 1, Column: 1}))
+
+			_, ok = locator.RightSourceMap().Lookup([]Position{Name("debug")})
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Does not record positions by default", func() {
+			diff, err := New().Compare(left, right)
+			Expect(err).To(BeNil())
+			_, ok := diff.(SourceLocator)
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
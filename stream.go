@@ -0,0 +1,367 @@
+package gojsondiff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+)
+
+// StreamConfig configures CompareStreams.
+type StreamConfig struct {
+	// ArrayWindow is the number of array elements buffered on each side to
+	// align arrays. Elements shifted further than the window are reported as
+	// deleted and added instead of moved or unchanged.
+	ArrayWindow int
+}
+
+// StreamDefaultConfig is the default configuration for CompareStreams.
+var StreamDefaultConfig = StreamConfig{
+	ArrayWindow: 64,
+}
+
+// A DeltaHandler receives a Delta and the path of its parent from the root.
+type DeltaHandler func(path []Position, delta Delta) error
+
+// CompareStreams compares two JSON documents read from the streams without
+// decoding them entirely, and calls fn for each Delta as soon as it is found.
+//
+// Objects are compared key by key while keys appear in the same order on both
+// sides. Values of keys that appear in different orders are decoded and kept
+// in memory until their counterparts are found. Arrays are aligned within a
+// window of elements given by the config, and their elements are decoded one
+// by one. The deltas are reported in the order of the streams, and Object and
+// Array deltas are given for values compared in memory.
+func (differ *Differ) CompareStreams(
+	left io.Reader,
+	right io.Reader,
+	config StreamConfig,
+	fn DeltaHandler,
+) error {
+	if config.ArrayWindow < 1 {
+		config.ArrayWindow = 1
+	}
+	s := &streamComparer{
+		differ: differ.start(context.Background()),
+		left:   json.NewDecoder(left),
+		right:  json.NewDecoder(right),
+		window: config.ArrayWindow,
+		fn:     fn,
+	}
+	return s.compareRoot()
+}
+
+type streamComparer struct {
+	differ *Differ
+	left   *json.Decoder
+	right  *json.Decoder
+	window int
+	fn     DeltaHandler
+}
+
+type streamItem struct {
+	index int
+	value interface{}
+	hash  uint64
+}
+
+func (s *streamComparer) compareRoot() error {
+	leftToken, err := s.left.Token()
+	if err != nil {
+		return err
+	}
+	rightToken, err := s.right.Token()
+	if err != nil {
+		return err
+	}
+	switch {
+	case leftToken == json.Delim('{') && rightToken == json.Delim('{'):
+		return s.compareObjects([]Position{})
+	case leftToken == json.Delim('[') && rightToken == json.Delim('['):
+		return s.compareArrays([]Position{})
+	}
+	return errors.New("documents must be both objects or both arrays")
+}
+
+func (s *streamComparer) compareValues(path []Position, position Position) error {
+	leftToken, err := s.left.Token()
+	if err != nil {
+		return err
+	}
+	rightToken, err := s.right.Token()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case leftToken == json.Delim('{') && rightToken == json.Delim('{'):
+		return s.compareObjects(childPosition(path, position))
+	case leftToken == json.Delim('[') && rightToken == json.Delim('['):
+		return s.compareArrays(childPosition(path, position))
+	}
+
+	leftValue, err := readValue(s.left, leftToken)
+	if err != nil {
+		return err
+	}
+	rightValue, err := readValue(s.right, rightToken)
+	if err != nil {
+		return err
+	}
+	return s.emitIfChanged(path, position, leftValue, rightValue)
+}
+
+func (s *streamComparer) compareObjects(path []Position) error {
+	leftPending := map[string]interface{}{}
+	rightPending := map[string]interface{}{}
+	leftDone, rightDone := false, false
+	for !leftDone || !rightDone {
+		var leftKey, rightKey string
+		var err error
+		if !leftDone {
+			if leftKey, leftDone, err = readKey(s.left); err != nil {
+				return err
+			}
+		}
+		if !rightDone {
+			if rightKey, rightDone, err = readKey(s.right); err != nil {
+				return err
+			}
+		}
+
+		if !leftDone && !rightDone && leftKey == rightKey {
+			if err := s.compareValues(path, Name(leftKey)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !leftDone {
+			leftValue, err := decodeValue(s.left)
+			if err != nil {
+				return err
+			}
+			if rightValue, ok := rightPending[leftKey]; ok {
+				delete(rightPending, leftKey)
+				if err := s.emitIfChanged(path, Name(leftKey), leftValue, rightValue); err != nil {
+					return err
+				}
+			} else {
+				leftPending[leftKey] = leftValue
+			}
+		}
+		if !rightDone {
+			rightValue, err := decodeValue(s.right)
+			if err != nil {
+				return err
+			}
+			if leftValue, ok := leftPending[rightKey]; ok {
+				delete(leftPending, rightKey)
+				if err := s.emitIfChanged(path, Name(rightKey), leftValue, rightValue); err != nil {
+					return err
+				}
+			} else {
+				rightPending[rightKey] = rightValue
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(leftPending) {
+		if err := s.fn(path, NewDeleted(Name(name), leftPending[name])); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(rightPending) {
+		if err := s.fn(path, NewAdded(Name(name), rightPending[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareArrays aligns elements greedily within the window: an element that
+// appears later on the other side makes the elements before it added or
+// deleted, and a pair of elements without counterparts is compared.
+func (s *streamComparer) compareArrays(path []Position) error {
+	leftBuffer := make([]streamItem, 0, s.window)
+	rightBuffer := make([]streamItem, 0, s.window)
+	leftIndex, rightIndex := 0, 0
+	leftDone, rightDone := false, false
+	var err error
+	for {
+		if leftBuffer, leftIndex, leftDone, err = s.fill(s.left, leftBuffer, leftIndex, leftDone); err != nil {
+			return err
+		}
+		if rightBuffer, rightIndex, rightDone, err = s.fill(s.right, rightBuffer, rightIndex, rightDone); err != nil {
+			return err
+		}
+
+		switch {
+		case len(leftBuffer) == 0 && len(rightBuffer) == 0:
+			return nil
+		case len(leftBuffer) == 0:
+			err = s.fn(path, NewAdded(Index(rightBuffer[0].index), rightBuffer[0].value))
+			rightBuffer = rightBuffer[1:]
+		case len(rightBuffer) == 0:
+			err = s.fn(path, NewDeleted(Index(leftBuffer[0].index), leftBuffer[0].value))
+			leftBuffer = leftBuffer[1:]
+		case sameItem(leftBuffer[0], rightBuffer[0]):
+			leftBuffer, rightBuffer = leftBuffer[1:], rightBuffer[1:]
+		default:
+			inRight := findItem(rightBuffer, leftBuffer[0])
+			inLeft := findItem(leftBuffer, rightBuffer[0])
+			switch {
+			case inRight > 0 && (inLeft < 0 || inRight <= inLeft):
+				for _, item := range rightBuffer[:inRight] {
+					if err = s.fn(path, NewAdded(Index(item.index), item.value)); err != nil {
+						return err
+					}
+				}
+				rightBuffer = rightBuffer[inRight:]
+			case inLeft > 0:
+				for _, item := range leftBuffer[:inLeft] {
+					if err = s.fn(path, NewDeleted(Index(item.index), item.value)); err != nil {
+						return err
+					}
+				}
+				leftBuffer = leftBuffer[inLeft:]
+			default:
+				err = s.emitIfChanged(path, Index(rightBuffer[0].index), leftBuffer[0].value, rightBuffer[0].value)
+				leftBuffer, rightBuffer = leftBuffer[1:], rightBuffer[1:]
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// fill reads array elements until the buffer holds window elements or the
+// array ends.
+func (s *streamComparer) fill(decoder *json.Decoder, buffer []streamItem, index int, done bool) ([]streamItem, int, bool, error) {
+	for !done && len(buffer) < s.window {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, 0, false, err
+		}
+		if token == json.Delim(']') {
+			done = true
+			break
+		}
+		value, err := readValue(decoder, token)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		hash, err := hashValue(value)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		buffer = append(buffer, streamItem{index: index, value: value, hash: hash})
+		index++
+	}
+	return buffer, index, done, nil
+}
+
+func (s *streamComparer) emitIfChanged(path []Position, position Position, left, right interface{}) error {
+	same, delta := s.differ.compareValues(path, position, left, right, nil)
+	if same {
+		return nil
+	}
+	return s.fn(path, delta)
+}
+
+func childPosition(path []Position, position Position) []Position {
+	child := make([]Position, len(path), len(path)+1)
+	copy(child, path)
+	return append(child, position)
+}
+
+// readKey reads the next key of an object, or the end of the object.
+func readKey(decoder *json.Decoder) (key string, done bool, err error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return "", false, err
+	}
+	if token == json.Delim('}') {
+		return "", true, nil
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", false, fmt.Errorf("unexpected token %v", token)
+	}
+	return key, false, nil
+}
+
+func decodeValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	return readValue(decoder, token)
+}
+
+// readValue reads the rest of the value that starts with the token.
+func readValue(decoder *json.Decoder, token json.Token) (interface{}, error) {
+	switch token {
+	case json.Delim('{'):
+		object := map[string]interface{}{}
+		for {
+			key, done, err := readKey(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				return object, nil
+			}
+			if object[key], err = decodeValue(decoder); err != nil {
+				return nil, err
+			}
+		}
+	case json.Delim('['):
+		array := []interface{}{}
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			if token == json.Delim(']') {
+				return array, nil
+			}
+			value, err := readValue(decoder, token)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+	}
+	if _, ok := token.(json.Delim); ok {
+		return nil, fmt.Errorf("unexpected token %v", token)
+	}
+	return token, nil
+}
+
+func hashValue(value interface{}) (uint64, error) {
+	bytes, err := json.Marshal(value) // keys are sorted
+	if err != nil {
+		return 0, err
+	}
+	hash := fnv.New64a()
+	hash.Write(bytes)
+	return hash.Sum64(), nil
+}
+
+func sameItem(left, right streamItem) bool {
+	return left.hash == right.hash && reflect.DeepEqual(left.value, right.value)
+}
+
+func findItem(items []streamItem, target streamItem) int {
+	for i, item := range items {
+		if sameItem(item, target) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,105 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/yudai/gojsondiff/tests"
+
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/yudai/gojsondiff/formatter"
+)
+
+var _ = Describe("Stream", func() {
+	compare := func(left, right string, config StreamConfig) ([]formatter.Change, error) {
+		changes := []formatter.Change{}
+		err := New().CompareStreams(strings.NewReader(left), strings.NewReader(right), config,
+			func(path []Position, delta Delta) error {
+				changes = append(changes, formatter.DeltaChanges(path, delta)...)
+				return nil
+			})
+		return changes, err
+	}
+
+	paths := func(changes []formatter.Change) (result []string) {
+		for _, change := range changes {
+			result = append(result, change.Kind+" "+change.PathString())
+		}
+		return
+	}
+
+	It("Finds the same changes as Compare", func() {
+		left, err := os.Open("FIXTURES/base.json")
+		Expect(err).To(BeNil())
+		defer left.Close()
+		right, err := os.Open("FIXTURES/base_changed.json")
+		Expect(err).To(BeNil())
+		defer right.Close()
+
+		changes := []formatter.Change{}
+		err = New().CompareStreams(left, right, StreamDefaultConfig, func(path []Position, delta Delta) error {
+			changes = append(changes, formatter.DeltaChanges(path, delta)...)
+			return nil
+		})
+		Expect(err).To(BeNil())
+
+		expected := formatter.CollectChanges(New().CompareObjects(
+			LoadFixture("FIXTURES/base.json"),
+			LoadFixture("FIXTURES/base_changed.json"),
+		))
+		Expect(changes).To(ConsistOf(expected))
+	})
+
+	It("Matches keys in different orders", func() {
+		changes, err := compare(`{"a": 1, "b": {"c": 2}, "d": 3}`, `{"b": {"c": 4}, "e": 5, "a": 1}`, StreamDefaultConfig)
+		Expect(err).To(BeNil())
+		Expect(paths(changes)).To(Equal([]string{"modified b.c", "deleted d", "added e"}))
+	})
+
+	It("Streams nested objects and arrays with the same keys", func() {
+		changes, err := compare(`{"a": {"b": [1, 2, 3, 4]}}`, `{"a": {"b": [1, 9, 2, 4, 5]}}`, StreamDefaultConfig)
+		Expect(err).To(BeNil())
+		Expect(paths(changes)).To(Equal([]string{
+			"added a.b[1]", "deleted a.b[2]", "added a.b[4]",
+		}))
+	})
+
+	It("Compares elements without counterparts in arrays", func() {
+		changes, err := compare(`[{"id": 1, "v": "a"}, 2]`, `[{"id": 1, "v": "b"}, 2]`, StreamDefaultConfig)
+		Expect(err).To(BeNil())
+		Expect(paths(changes)).To(Equal([]string{"modified [0].v"}))
+	})
+
+	It("Aligns arrays only within the window", func() {
+		changes, err := compare(`[1, 2, 3, 4]`, `[5, 6, 1, 2, 3, 4]`, StreamConfig{ArrayWindow: 2})
+		Expect(err).To(BeNil())
+		Expect(paths(changes)).To(Equal([]string{
+			"modified [0]", "modified [1]", "modified [2]", "modified [3]", "added [4]", "added [5]",
+		}))
+
+		changes, err = compare(`[1, 2, 3, 4]`, `[5, 6, 1, 2, 3, 4]`, StreamConfig{ArrayWindow: 3})
+		Expect(err).To(BeNil())
+		Expect(paths(changes)).To(Equal([]string{"added [0]", "added [1]"}))
+	})
+
+	It("Rejects documents of different types", func() {
+		_, err := compare(`{}`, `[]`, StreamDefaultConfig)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Stops when the handler returns an error", func() {
+		stop := errors.New("stop")
+		count := 0
+		err := New().CompareStreams(strings.NewReader(`[1, 2]`), strings.NewReader(`[3, 4]`), StreamDefaultConfig,
+			func(path []Position, delta Delta) error {
+				count++
+				return stop
+			})
+		Expect(err).To(Equal(stop))
+		Expect(count).To(Equal(1))
+	})
+})
@@ -0,0 +1,172 @@
+package gojsondiff
+
+import (
+	"strings"
+	"unicode"
+
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// A TextMode is the granularity of text diffs.
+type TextMode int
+
+const (
+	// TextByCharacters compares texts by characters. This is the default.
+	TextByCharacters TextMode = iota
+	// TextByWords compares texts by words, spaces and punctuations.
+	TextByWords
+	// TextByLines compares texts by lines, which suits multi-line texts
+	// such as scripts, queries and certificates.
+	TextByLines
+	// TextAuto compares texts containing newlines by lines and other texts
+	// by characters.
+	TextAuto
+)
+
+// WithTextMode sets the granularity of text diffs.
+func WithTextMode(mode TextMode) Option {
+	return func(differ *Differ) {
+		differ.textMode = mode
+	}
+}
+
+// WithTextModeAt sets the granularity of text diffs for strings at paths
+// matching the pattern, as in WithPathComparator. The first matching pattern
+// takes precedence over later ones and WithTextMode.
+func WithTextModeAt(pattern string, mode TextMode) Option {
+	rule := textModeRule{segments: parsePattern(pattern), mode: mode}
+	return func(differ *Differ) {
+		differ.textModes = append(differ.textModes[:len(differ.textModes):len(differ.textModes)], rule)
+	}
+}
+
+type textModeRule struct {
+	segments []string
+	mode     TextMode
+}
+
+// textModeAt returns the mode for the texts at the path. TextAuto is
+// resolved into TextByLines or TextByCharacters.
+func (differ *Differ) textModeAt(path []Position, left, right string) TextMode {
+	mode := differ.textMode
+	for _, rule := range differ.textModes {
+		if matchPattern(rule.segments, path) {
+			mode = rule.mode
+			break
+		}
+	}
+	if mode == TextAuto {
+		if strings.Contains(left, "\n") || strings.Contains(right, "\n") {
+			return TextByLines
+		}
+		return TextByCharacters
+	}
+	return mode
+}
+
+// DiffTexts returns the differences between two texts in the mode.
+func DiffTexts(oldText, newText string, mode TextMode) []dmp.Diff {
+	differ := dmp.New()
+	switch mode {
+	case TextByWords, TextByLines:
+		split := splitWords
+		if mode == TextByLines {
+			split = splitLines
+		}
+		if oldRunes, newRunes, tokens, ok := tokensToRunes(oldText, newText, split); ok {
+			diffs := differ.DiffMainRunes(oldRunes, newRunes, false)
+			return differ.DiffCharsToLines(diffs, tokens)
+		}
+	case TextAuto:
+		if strings.Contains(oldText, "\n") || strings.Contains(newText, "\n") {
+			return DiffTexts(oldText, newText, TextByLines)
+		}
+	}
+	return differ.DiffCleanupSemantic(differ.DiffMain(oldText, newText, false))
+}
+
+// textPatches returns the patches from the left text to the right text.
+func textPatches(left, right string, mode TextMode) []dmp.Patch {
+	textDiff := dmp.New()
+	if mode == TextByCharacters {
+		return textDiff.PatchMake(left, right)
+	}
+	return textDiff.PatchMake(left, DiffTexts(left, right, mode))
+}
+
+const (
+	surrogateMin = 0xD800
+	surrogateMax = 0xDFFF
+)
+
+// tokensToRunes splits two texts into tokens and reduces the texts to runes
+// where each rune represents a token, like DiffLinesToRunes does for lines.
+// The runes are valid code points, so tokens[r] is the token of the rune r
+// even after the runes are converted to strings. ok is false when the texts
+// have more distinct tokens than code points.
+func tokensToRunes(text1, text2 string, split func(string) []string) (runes1, runes2 []rune, tokens []string, ok bool) {
+	tokens = []string{""} // avoid generating a null character
+	tokenHash := map[string]rune{}
+
+	munge := func(text string) []rune {
+		runes := []rune{}
+		for _, token := range split(text) {
+			r, found := tokenHash[token]
+			if !found {
+				if len(tokens) == surrogateMin {
+					// surrogates are replaced when converted to strings
+					for len(tokens) <= surrogateMax {
+						tokens = append(tokens, "")
+					}
+				}
+				r = rune(len(tokens))
+				if r > unicode.MaxRune {
+					ok = false
+					return nil
+				}
+				tokens = append(tokens, token)
+				tokenHash[token] = r
+			}
+			runes = append(runes, r)
+		}
+		return runes
+	}
+
+	ok = true
+	if runes1 = munge(text1); ok {
+		runes2 = munge(text2)
+	}
+	return runes1, runes2, tokens, ok
+}
+
+// splitLines splits a text into lines keeping their line breaks.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func splitWords(text string) (words []string) {
+	start := 0
+	runes := []rune(text)
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || wordClass(runes[i]) != wordClass(runes[i-1]) || wordClass(runes[i]) == 2 {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return words
+}
+
+// wordClass returns 0 for letters and digits, 1 for spaces and 2 for others
+func wordClass(r rune) int {
+	switch {
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return 0
+	case unicode.IsSpace(r):
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,85 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+
+	"fmt"
+	"strings"
+)
+
+var _ = Describe("Text modes", func() {
+	script := "SELECT id, name\nFROM users\nWHERE active = true\nORDER BY id\n"
+	changed := "SELECT id, name\nFROM accounts\nWHERE active = true\nORDER BY id\n"
+	left := map[string]interface{}{"query": script, "title": "the quick brown fox jumps over"}
+	right := map[string]interface{}{"query": changed, "title": "the quick brown cat jumps over"}
+
+	textDiffs := func(differ *Differ) map[string]*TextDiff {
+		result := map[string]*TextDiff{}
+		for _, delta := range differ.CompareObjects(left, right).Deltas() {
+			d := delta.(*TextDiff)
+			result[d.Position.String()] = d
+		}
+		return result
+	}
+
+	It("diffs texts by lines", func() {
+		d := textDiffs(New(WithTextMode(TextByLines)))["query"]
+		Expect(d.Mode).To(Equal(TextByLines))
+		Expect(d.DiffString()).To(ContainSubstring("\n-FROM users%0A\n"))
+		Expect(d.DiffString()).To(ContainSubstring("\n+FROM accounts%0A\n"))
+	})
+
+	It("diffs texts by words", func() {
+		Expect(DiffTexts("the brown fox", "the brown cat", TextByWords)).To(Equal([]dmp.Diff{
+			{Type: dmp.DiffEqual, Text: "the brown "},
+			{Type: dmp.DiffDelete, Text: "fox"},
+			{Type: dmp.DiffInsert, Text: "cat"},
+		}))
+	})
+
+	It("selects modes by paths and newlines", func() {
+		diffs := textDiffs(New(WithTextMode(TextAuto)))
+		Expect(diffs["query"].Mode).To(Equal(TextByLines))
+		Expect(diffs["title"].Mode).To(Equal(TextByCharacters))
+
+		diffs = textDiffs(New(WithTextModeAt("/title", TextByWords), WithTextModeAt("/**", TextByLines)))
+		Expect(diffs["query"].Mode).To(Equal(TextByLines))
+		Expect(diffs["title"].Mode).To(Equal(TextByWords))
+	})
+
+	It("patches texts in all modes", func() {
+		for _, mode := range []TextMode{TextByCharacters, TextByWords, TextByLines, TextAuto} {
+			d := New(WithTextMode(mode)).CompareObjects(left, right)
+			patched := map[string]interface{}{"query": script, "title": left["title"]}
+			New().ApplyPatch(patched, d)
+			Expect(patched).To(Equal(right))
+		}
+	})
+
+	It("diffs texts with more distinct tokens than the surrogate range", func() {
+		words, lines := make([]string, 60001), make([]string, 60001)
+		for i := range words {
+			words[i] = fmt.Sprintf("w%d", i)
+			lines[i] = words[i] + "\n"
+		}
+		oldWords, newWords := strings.Join(words[:60000], " "), strings.Join(words[1:], " ")
+		oldLines, newLines := strings.Join(lines[:60000], ""), strings.Join(lines[1:], "")
+
+		for _, texts := range [][3]interface{}{{oldWords, newWords, TextByWords}, {oldLines, newLines, TextByLines}} {
+			diffs := DiffTexts(texts[0].(string), texts[1].(string), texts[2].(TextMode))
+			Expect(dmp.New().DiffText1(diffs)).To(Equal(texts[0]))
+			Expect(dmp.New().DiffText2(diffs)).To(Equal(texts[1]))
+		}
+
+		d := New(WithTextMode(TextByWords)).CompareObjects(
+			map[string]interface{}{"text": oldWords}, map[string]interface{}{"text": newWords})
+		patched := map[string]interface{}{"text": oldWords}
+		New().ApplyPatch(patched, d)
+		Expect(patched["text"]).To(Equal(newWords))
+	})
+})
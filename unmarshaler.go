@@ -36,6 +36,10 @@ func (um *Unmarshaller) UnmarshalObject(diffObj map[string]interface{}) (Diff, e
 	if err != nil {
 		return nil, err
 	}
+	switch result.(type) {
+	case *Array:
+		return &diff{deltas: result.(*Array).Deltas}, nil
+	}
 	return &diff{deltas: result.(*Object).Deltas}, nil
 }
 
@@ -68,28 +72,7 @@ func process(position Position, object interface{}) (Delta, error) {
 				deltas = append(deltas, childDelta)
 			}
 
-			for _, d := range deltas {
-				switch d.(type) {
-				case *Moved:
-					moved := d.(*Moved)
-
-					var dd interface{}
-					var i int
-					for i, dd = range deltas {
-						switch dd.(type) {
-						case *Moved:
-						case PostDelta:
-							pd := dd.(PostDelta)
-							if moved.PostPosition() == pd.PostPosition() {
-								moved.Delta = pd
-								deltas = append(deltas[:i], deltas[i+1:]...)
-							}
-						}
-					}
-				}
-			}
-
-			delta = NewArray(position, deltas)
+			delta = NewArray(position, attachMovedDeltas(deltas))
 		} else {
 			deltas := make([]Delta, 0, len(o))
 			for name, value := range o {
@@ -99,7 +82,7 @@ func process(position Position, object interface{}) (Delta, error) {
 				}
 				deltas = append(deltas, childDelta)
 			}
-			delta = NewObject(position, deltas)
+			delta = NewObject(position, attachMovedDeltas(deltas))
 		}
 	case []interface{}:
 		o := object.([]interface{})
@@ -120,7 +103,27 @@ func process(position Position, object interface{}) (Delta, error) {
 				}
 				delta = NewTextDiff(position, patches, nil, nil)
 			case float64(3):
-				delta = NewMoved(position, Index(int(o[1].(float64))), nil, nil)
+				switch to := o[1].(type) {
+				case float64:
+					delta = NewMoved(position, Index(int(to)), nil, nil)
+				case string:
+					delta = NewMoved(position, Name(to), nil, nil)
+				default:
+					return nil, errors.New("Invalid move destination")
+				}
+			case float64(4):
+				document, err := process(position, o[0])
+				if err != nil {
+					return nil, err
+				}
+				switch document.(type) {
+				case *Object:
+					delta = NewEmbedded(position, document.(*Object).Deltas, nil, nil)
+				case *Array:
+					delta = NewEmbedded(position, document.(*Array).Deltas, nil, nil)
+				default:
+					return nil, errors.New("Invalid embedded delta")
+				}
 			default:
 				return nil, errors.New("Unknown delta type")
 			}
@@ -129,3 +132,31 @@ func process(position Position, object interface{}) (Delta, error) {
 
 	return delta, nil
 }
+
+// attachMovedDeltas moves the Deltas at the destinations of Moved into the
+// Moved, as they are applied after moving.
+func attachMovedDeltas(deltas []Delta) []Delta {
+	destinations := map[Position]*Moved{}
+	for _, d := range deltas {
+		if moved, ok := d.(*Moved); ok {
+			destinations[moved.PostPosition()] = moved
+		}
+	}
+	if len(destinations) == 0 {
+		return deltas
+	}
+
+	remaining := make([]Delta, 0, len(deltas))
+	for _, d := range deltas {
+		if pd, ok := d.(PostDelta); ok {
+			if _, isMoved := d.(*Moved); !isMoved {
+				if moved, ok := destinations[pd.PostPosition()]; ok {
+					moved.Delta = pd
+					continue
+				}
+			}
+		}
+		remaining = append(remaining, d)
+	}
+	return remaining
+}
@@ -112,5 +112,15 @@ var _ = Describe("Gojsondiff", func() {
 				fmt.Println(string(result))
 			})
 		})
+		Describe("CompareArrays", func() {
+			It("Unmarshals diffs of root arrays", func() {
+				um := NewUnmarshaller()
+				diff, err := um.UnmarshalString(`{"_t": "a", "1": ["c"], "_1": ["b", 0, 0]}`)
+				Expect(err).To(BeNil())
+
+				patched := New().ApplyPatchToArray([]interface{}{"a", "b"}, diff)
+				Expect(patched).To(Equal([]interface{}{"a", "c"}))
+			})
+		})
 	})
 })
@@ -0,0 +1,476 @@
+package gojsondiff
+
+import (
+	"context"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompareValues compares two Go values, such as structs, maps, slices and
+// pointers to them, and return a Diff object.
+// The values are compared as their JSON encodings by encoding/json, but
+// without encoding them: `json` struct tags, json.Marshaler and
+// encoding.TextMarshaler are honoured. Both values must be encoded as JSON
+// objects or both as JSON arrays.
+//
+// The comparison is controlled by `diff` struct tags in the type of the left
+// value:
+//
+//	diff:"-"              ignore the field
+//	diff:"key=ID"         match elements of the slice by their field ID
+//	diff:"set"            compare the slice regardless of the order
+//	diff:"tolerance=0.01" treat numbers within the tolerance as equal
+//	diff:"redact"         hide the values of the field in Deltas
+//
+// Directives can be combined with commas, such as `diff:"set,redact"`.
+func (differ *Differ) CompareValues(left interface{}, right interface{}) (Diff, error) {
+	return differ.CompareValuesContext(context.Background(), left, right)
+}
+
+// CompareValuesContext is CompareValues honouring the context in the same way
+// as CompareContext.
+func (differ *Differ) CompareValuesContext(ctx context.Context, left interface{}, right interface{}) (Diff, error) {
+	differ = differ.start(ctx)
+	leftValue, err := JSONValue(left)
+	if err != nil {
+		return nil, err
+	}
+	rightValue, err := JSONValue(right)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := typePolicy(reflect.TypeOf(left))
+	if err != nil {
+		return nil, err
+	}
+	leftValue, rightValue = policy.prune(leftValue), policy.prune(rightValue)
+
+	switch l := leftValue.(type) {
+	case map[string]interface{}:
+		if r, ok := rightValue.(map[string]interface{}); ok {
+			return differ.finish(differ.compareMaps([]Position{}, l, r, policy))
+		}
+	case []interface{}:
+		if r, ok := rightValue.([]interface{}); ok {
+			return differ.finish(differ.compareArrays([]Position{}, l, r, policy))
+		}
+	}
+	return nil, fmt.Errorf("values must be both JSON objects or both JSON arrays, got %T and %T", left, right)
+}
+
+// CompareAt compares two Go values at the position as their JSON encodings,
+// and returns the Delta between them, or nil when they are the same.
+// It is used by code generated with jdgen for fields without specialized
+// comparison.
+func (differ *Differ) CompareAt(position Position, left interface{}, right interface{}) (Delta, error) {
+	leftValue, err := JSONValue(left)
+	if err != nil {
+		return nil, err
+	}
+	rightValue, err := JSONValue(right)
+	if err != nil {
+		return nil, err
+	}
+	differ = differ.start(context.Background())
+	if same, delta := differ.compareValues([]Position{}, position, leftValue, rightValue, nil); !same {
+		return delta, nil
+	}
+	return nil, nil
+}
+
+// JSONValue converts a Go value into the data model of encoding/json
+// (map[string]interface{}, []interface{}, string, float64, bool and nil),
+// which is what decoding the JSON encoding of the value produces.
+func JSONValue(value interface{}) (interface{}, error) {
+	return jsonValue(reflect.ValueOf(value))
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	numberType        = reflect.TypeOf(json.Number(""))
+)
+
+func jsonValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if marshaler, ok := asMarshaler(v, marshalerType); ok {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, nil
+		}
+		bytes, err := marshaler.(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		var decoded interface{}
+		err = json.Unmarshal(bytes, &decoded)
+		return decoded, err
+	}
+	if marshaler, ok := asMarshaler(v, textMarshalerType); ok {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, nil
+		}
+		text, err := marshaler.(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("unsupported value: %v", f)
+		}
+		if v.Kind() == reflect.Float32 {
+			return Float32Value(float32(f)), nil
+		}
+		return f, nil
+	case reflect.String:
+		if v.Type() == numberType {
+			if v.String() == "" {
+				return float64(0), nil
+			}
+			return strconv.ParseFloat(v.String(), 64)
+		}
+		return v.String(), nil
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return jsonValue(v.Elem())
+	case reflect.Map:
+		return mapValue(v)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+		return arrayValue(v)
+	case reflect.Array:
+		return arrayValue(v)
+	case reflect.Struct:
+		return structValue(v)
+	}
+	return nil, fmt.Errorf("unsupported type: %s", v.Type())
+}
+
+// Float32Value returns the float64 decoded from the JSON encoding of the
+// float32 by encoding/json, such as 0.1 rather than 0.10000000149011612.
+// It is used by code generated with jdgen.
+func Float32Value(f float32) float64 {
+	converted, _ := strconv.ParseFloat(strconv.FormatFloat(float64(f), 'g', -1, 32), 64)
+	return converted
+}
+
+// asMarshaler returns the value as the marshaler interface, also trying its
+// address for methods with pointer receivers.
+func asMarshaler(v reflect.Value, marshaler reflect.Type) (interface{}, bool) {
+	if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(marshaler) {
+		return v.Addr().Interface(), true
+	}
+	if v.Type().Implements(marshaler) && v.CanInterface() {
+		if v.Kind() == reflect.Interface && v.IsNil() {
+			return nil, false
+		}
+		return v.Interface(), true
+	}
+	return nil, false
+}
+
+func arrayValue(v reflect.Value) (interface{}, error) {
+	array := make([]interface{}, v.Len())
+	for i := range array {
+		item, err := jsonValue(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		array[i] = item
+	}
+	return array, nil
+}
+
+func mapValue(v reflect.Value) (interface{}, error) {
+	if v.IsNil() {
+		return nil, nil
+	}
+	object := make(map[string]interface{}, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		item, err := jsonValue(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		object[key] = item
+	}
+	return object, nil
+}
+
+func mapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if marshaler, ok := asMarshaler(key, textMarshalerType); ok {
+		text, err := marshaler.(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type: %s", key.Type())
+}
+
+func structValue(v reflect.Value) (interface{}, error) {
+	object := map[string]interface{}{}
+	for _, field := range cachedFields(v.Type()) {
+		fieldValue, ok := fieldByIndex(v, field.index)
+		if !ok {
+			continue // nil embedded pointer
+		}
+		if field.omitEmpty && isEmptyValue(fieldValue) {
+			continue
+		}
+		item, err := jsonValue(fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		if field.quoted {
+			item = quotedValue(item)
+		}
+		object[field.name] = item
+	}
+	return object, nil
+}
+
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// quotedValue applies the ",string" option of json tags.
+func quotedValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		bytes, _ := json.Marshal(v)
+		return string(bytes)
+	case float64, bool:
+		bytes, _ := json.Marshal(v)
+		return string(bytes)
+	}
+	return value
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// A structField is a field of a struct encoded by encoding/json.
+type structField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]structField
+
+func cachedFields(t reflect.Type) []structField {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]structField)
+	}
+	fields, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return fields.([]structField)
+}
+
+// typeFields returns the fields encoded by encoding/json for the type,
+// following its rules for embedded structs.
+func typeFields(t reflect.Type) []structField {
+	type candidate struct {
+		typ   reflect.Type
+		index []int
+	}
+	current := []candidate{}
+	next := []candidate{{typ: t}}
+	visited := map[reflect.Type]bool{}
+	seen := map[string]bool{} // names at shallower depths
+
+	fields := []structField{}
+	for len(next) > 0 {
+		current, next = next, []candidate{}
+		depthFields := []structField{}
+
+		for _, c := range current {
+			if visited[c.typ] {
+				continue
+			}
+			visited[c.typ] = true
+
+			for i := 0; i < c.typ.NumField(); i++ {
+				sf := c.typ.Field(i)
+				fieldType := sf.Type
+				if sf.Anonymous {
+					if fieldType.Kind() == reflect.Ptr {
+						fieldType = fieldType.Elem()
+					}
+					if sf.PkgPath != "" && fieldType.Kind() != reflect.Struct {
+						continue
+					}
+				} else if sf.PkgPath != "" {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, options := parseTag(tag)
+				index := make([]int, len(c.index)+1)
+				copy(index, c.index)
+				index[len(c.index)] = i
+
+				if name == "" && sf.Anonymous && fieldType.Kind() == reflect.Struct {
+					next = append(next, candidate{typ: fieldType, index: index})
+					continue
+				}
+
+				field := structField{
+					name:      name,
+					index:     index,
+					tagged:    name != "",
+					omitEmpty: options.contains("omitempty"),
+				}
+				if field.name == "" {
+					field.name = sf.Name
+				}
+				if options.contains("string") {
+					switch fieldType.Kind() {
+					case reflect.Bool, reflect.String,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64:
+						field.quoted = true
+					}
+				}
+				depthFields = append(depthFields, field)
+			}
+		}
+
+		fields = append(fields, dominantFields(seen, depthFields)...)
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+	return fields
+}
+
+// dominantFields returns the fields at a depth which are not hidden by
+// names seen at shallower depths. Among fields with the same name at the same
+// depth, only a single tagged field survives.
+func dominantFields(seen map[string]bool, fields []structField) []structField {
+	byName := map[string][]structField{}
+	names := []string{}
+	for _, field := range fields {
+		if seen[field.name] {
+			continue
+		}
+		if _, ok := byName[field.name]; !ok {
+			names = append(names, field.name)
+		}
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	dominant := []structField{}
+	for _, name := range names {
+		seen[name] = true
+		candidates := byName[name]
+		if len(candidates) == 1 {
+			dominant = append(dominant, candidates[0])
+			continue
+		}
+		tagged := []structField{}
+		for _, field := range candidates {
+			if field.tagged {
+				tagged = append(tagged, field)
+			}
+		}
+		if len(tagged) == 1 {
+			dominant = append(dominant, tagged[0])
+		}
+	}
+	return dominant
+}
+
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+type tagOptions string
+
+func parseTag(tag string) (string, tagOptions) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tagOptions(tag[i+1:])
+	}
+	return tag, ""
+}
+
+func (o tagOptions) contains(option string) bool {
+	for _, s := range strings.Split(string(o), ",") {
+		if s == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"encoding/json"
+	"net"
+	"time"
+)
+
+type valuesBase struct {
+	ID      int    `json:"id"`
+	Kind    string `json:"kind"`
+	Comment string
+}
+
+type valuesLabel string
+
+func (l valuesLabel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"label": string(l)})
+}
+
+type valuesItem struct {
+	valuesBase
+	Kind     string            `json:"type"`
+	Name     string            `json:"name,omitempty"`
+	Secret   string            `json:"-"`
+	Count    int64             `json:"count,string"`
+	Tags     []string          `json:"tags"`
+	Scores   map[int]float32   `json:"scores,omitempty"`
+	Data     []byte            `json:"data"`
+	Created  time.Time         `json:"created"`
+	IP       net.IP            `json:"ip"`
+	Label    valuesLabel       `json:"label"`
+	Next     *valuesItem       `json:"next,omitempty"`
+	Extra    interface{}       `json:"extra"`
+	Number   json.Number       `json:"number"`
+	Children [2]map[string]int `json:"children"`
+	private  int
+}
+
+var _ = Describe("Values", func() {
+	item := func() *valuesItem {
+		return &valuesItem{
+			valuesBase: valuesBase{ID: 1, Kind: "base", Comment: "hello"},
+			Kind:       "item",
+			Secret:     "s3cret",
+			Count:      42,
+			Tags:       []string{"a", "b"},
+			Scores:     map[int]float32{1: 0.5},
+			Data:       []byte("bytes"),
+			Created:    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			IP:         net.IPv4(10, 0, 0, 1),
+			Label:      "x",
+			Next:       &valuesItem{Kind: "next"},
+			Extra:      map[string]interface{}{"k": []int{1}},
+			Number:     "1.5",
+			private:    1,
+		}
+	}
+
+	roundTrip := func(value interface{}) interface{} {
+		bytes, err := json.Marshal(value)
+		Expect(err).To(BeNil())
+		var decoded interface{}
+		Expect(json.Unmarshal(bytes, &decoded)).To(Succeed())
+		return decoded
+	}
+
+	Describe("JSONValue", func() {
+		It("Converts Go values as encoding/json does", func() {
+			value, err := JSONValue(item())
+			Expect(err).To(BeNil())
+			Expect(value).To(Equal(roundTrip(item())))
+
+			object := value.(map[string]interface{})
+			Expect(object).To(HaveKeyWithValue("type", "item"))
+			Expect(object).To(HaveKeyWithValue("kind", "base"))
+			Expect(object).To(HaveKeyWithValue("count", "42"))
+			Expect(object).NotTo(HaveKey("Secret"))
+			Expect(object).NotTo(HaveKey("name"))
+		})
+
+		It("Converts nil values, scalars and slices", func() {
+			var nilItem *valuesItem
+			for _, value := range []interface{}{nil, nilItem, 1, "s", true, float32(0.1), []float32{1.1, 3.3}, []valuesItem{{}}, map[string][]int{"a": nil}} {
+				converted, err := JSONValue(value)
+				Expect(err).To(BeNil())
+				Expect([]interface{}{converted}).To(Equal([]interface{}{roundTrip(value)}))
+			}
+		})
+
+		It("Rejects unsupported values", func() {
+			_, err := JSONValue(map[string]interface{}{"f": func() {}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("CompareValues", func() {
+		It("Generates the same diff as comparing JSON encodings", func() {
+			a := item()
+			b := item()
+			b.Kind = "changed"
+			b.Name = "new"
+			b.Tags = append(b.Tags, "c")
+			b.Next.Count = 3
+			b.Secret = "ignored"
+
+			differ := New()
+			diff, err := differ.CompareValues(a, b)
+			Expect(err).To(BeNil())
+			Expect(diff.Modified()).To(BeTrue())
+
+			aJson, _ := json.Marshal(a)
+			bJson, _ := json.Marshal(b)
+			expected, err := differ.Compare(aJson, bJson)
+			Expect(err).To(BeNil())
+			Expect(diff).To(Equal(expected))
+		})
+
+		It("Compares slices", func() {
+			diff, err := New().CompareValues([]int{1, 2}, []int{1, 2, 3})
+			Expect(err).To(BeNil())
+			Expect(diff.Deltas()).To(HaveLen(1))
+		})
+
+		It("Rejects values that are not objects or arrays", func() {
+			_, err := New().CompareValues(1, 2)
+			Expect(err).To(HaveOccurred())
+			_, err = New().CompareValues(item(), []int{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
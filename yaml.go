@@ -0,0 +1,146 @@
+package gojsondiff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// UnmarshalYAML parses a YAML stream, which can contain multiple documents,
+// and returns the documents normalized to the data model of encoding/json
+// (map[string]interface{}, []interface{}, string, float64, bool and nil)
+// so that they can be compared by Differ.
+func UnmarshalYAML(data []byte) (documents []interface{}, err error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	documents = make([]interface{}, 0, 1)
+	for {
+		var document interface{}
+		err = decoder.Decode(&document)
+		if err == io.EOF {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, normalizeYAML(document))
+	}
+}
+
+// MarshalYAML encodes documents into a YAML stream.
+// Multiple documents are separated by "---".
+func MarshalYAML(documents ...interface{}) ([]byte, error) {
+	buffer := bytes.NewBuffer([]byte{})
+	for i, document := range documents {
+		if i > 0 {
+			buffer.WriteString("---\n")
+		}
+		bytes, err := yaml.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(bytes)
+	}
+	return buffer.Bytes(), nil
+}
+
+// YAMLRoot returns the value of a YAML stream read alone: the document
+// itself for a stream with a single document and an array of the documents
+// otherwise. Use YAMLRoots to compare two streams.
+func YAMLRoot(documents []interface{}) interface{} {
+	if len(documents) == 1 {
+		return documents[0]
+	}
+	return documents
+}
+
+// YAMLRoots returns the values to compare for two YAML streams: the documents
+// themselves when both streams have a single document and the arrays of the
+// documents otherwise, so that a stream is never taken for a sequence.
+func YAMLRoots(left, right []interface{}) (leftRoot, rightRoot interface{}) {
+	if len(left) == 1 && len(right) == 1 {
+		return left[0], right[0]
+	}
+	return left, right
+}
+
+// CompareYAML compares two YAML streams and return a Diff object.
+// Streams are compared by the values returned by YAMLRoots.
+func (differ *Differ) CompareYAML(
+	left []byte,
+	right []byte,
+) (Diff, error) {
+	leftDocuments, err := UnmarshalYAML(left)
+	if err != nil {
+		return nil, err
+	}
+	rightDocuments, err := UnmarshalYAML(right)
+	if err != nil {
+		return nil, err
+	}
+
+	leftRoot, rightRoot := YAMLRoots(leftDocuments, rightDocuments)
+	switch l := leftRoot.(type) {
+	case map[string]interface{}:
+		if r, ok := rightRoot.(map[string]interface{}); ok {
+			return differ.CompareObjects(l, r), nil
+		}
+	case []interface{}:
+		if r, ok := rightRoot.([]interface{}); ok {
+			return differ.CompareArrays(l, r), nil
+		}
+	}
+	return nil, errors.New("YAML documents must be both mappings or both sequences")
+}
+
+// MarshalYAML encodes the object into a YAML mapping with its keys in order.
+func (m *OrderedMap) MarshalYAML() (interface{}, error) {
+	items := make(yaml.MapSlice, len(m.keys))
+	for i, key := range m.keys {
+		items[i] = yaml.MapItem{Key: key, Value: m.values[key]}
+	}
+	return items, nil
+}
+
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[yamlKey(key)] = normalizeYAML(item)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, item := range v {
+			a[i] = normalizeYAML(item)
+		}
+		return a
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	}
+	return value
+}
+
+func yamlKey(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(normalizeYAML(key))
+}
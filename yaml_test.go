@@ -0,0 +1,131 @@
+package gojsondiff_test
+
+import (
+	. "github.com/yudai/gojsondiff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/yudai/gojsondiff/tests"
+
+	"io/ioutil"
+
+	"github.com/yudai/gojsondiff/formatter"
+)
+
+var _ = Describe("YAML", func() {
+	Describe("UnmarshalYAML", func() {
+		It("Normalizes documents to the JSON data model", func() {
+			documents, err := UnmarshalYAML([]byte("int: 1\nfloat: 1.5\n2: two\nlist: [true, null]\nnested: {key: value}\n"))
+			Expect(err).To(BeNil())
+			Expect(documents).To(Equal([]interface{}{
+				map[string]interface{}{
+					"int":    float64(1),
+					"float":  float64(1.5),
+					"2":      "two",
+					"list":   []interface{}{true, nil},
+					"nested": map[string]interface{}{"key": "value"},
+				},
+			}))
+		})
+
+		It("Reads all documents in a stream", func() {
+			content, err := ioutil.ReadFile("FIXTURES/multi_document.yaml")
+			Expect(err).To(BeNil())
+			documents, err := UnmarshalYAML(content)
+			Expect(err).To(BeNil())
+			Expect(documents).To(HaveLen(2))
+			Expect(YAMLRoot(documents)).To(Equal(documents))
+		})
+	})
+
+	Describe("CompareYAML", func() {
+		It("Generates the same diff as the equivalent JSON", func() {
+			a, err := ioutil.ReadFile("FIXTURES/base.yaml")
+			Expect(err).To(BeNil())
+			b, err := ioutil.ReadFile("FIXTURES/base_changed.yaml")
+			Expect(err).To(BeNil())
+
+			differ := New()
+			diff, err := differ.CompareYAML(a, b)
+			Expect(err).To(BeNil())
+			Expect(diff).To(Equal(differ.CompareObjects(
+				LoadFixture("FIXTURES/base.json"),
+				LoadFixture("FIXTURES/base_changed.json"),
+			)))
+		})
+
+		It("Compares multi-document streams and applies the diff", func() {
+			a, err := ioutil.ReadFile("FIXTURES/multi_document.yaml")
+			Expect(err).To(BeNil())
+			b, err := ioutil.ReadFile("FIXTURES/multi_document_changed.yaml")
+			Expect(err).To(BeNil())
+
+			differ := New()
+			diff, err := differ.CompareYAML(a, b)
+			Expect(err).To(BeNil())
+			Expect(diff.Modified()).To(BeTrue())
+
+			left, _ := UnmarshalYAML(a)
+			right, _ := UnmarshalYAML(b)
+			Expect(differ.ApplyPatchToArray(left, diff)).To(Equal(right))
+
+			deltaJson, err := formatter.NewDeltaFormatter().Format(diff)
+			Expect(err).To(BeNil())
+			unmarshaled, err := NewUnmarshaller().UnmarshalString(deltaJson)
+			Expect(err).To(BeNil())
+			left, _ = UnmarshalYAML(a)
+			Expect(differ.ApplyPatchToArray(left, unmarshaled)).To(Equal(right))
+		})
+
+		It("Compares a single sequence and a stream as different", func() {
+			diff, err := New().CompareYAML([]byte("[1, 2]\n"), []byte("1\n---\n2\n"))
+			Expect(err).To(BeNil())
+			Expect(diff.Modified()).To(BeTrue())
+		})
+
+		It("Compares a single mapping with a stream as arrays of documents", func() {
+			a := []byte("key: value\n")
+			b := []byte("key: value\n---\nkey: other\n")
+
+			differ := New()
+			diff, err := differ.CompareYAML(a, b)
+			Expect(err).To(BeNil())
+			Expect(diff.Modified()).To(BeTrue())
+
+			left, _ := UnmarshalYAML(a)
+			right, _ := UnmarshalYAML(b)
+			Expect(differ.ApplyPatchToArray(left, diff)).To(Equal(right))
+		})
+
+		It("Fails with documents of different kinds", func() {
+			_, err := New().CompareYAML([]byte("key: value"), []byte("- value"))
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("MarshalYAML", func() {
+		It("Writes documents back as a stream", func() {
+			content, err := ioutil.ReadFile("FIXTURES/multi_document_changed.yaml")
+			Expect(err).To(BeNil())
+			documents, err := UnmarshalYAML(content)
+			Expect(err).To(BeNil())
+
+			result, err := MarshalYAML(documents...)
+			Expect(err).To(BeNil())
+			Expect(string(result)).To(Equal(`kind: Service
+metadata:
+  name: web
+---
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 5
+---
+data:
+  "1": one
+kind: ConfigMap
+`))
+		})
+	})
+})